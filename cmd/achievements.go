@@ -0,0 +1,331 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// Achievement is a goal that carries over from game to game.  It counts the
+// records whose key is in On, each worth one or the number in its Amount
+// field, and is unlocked once Count of them have been seen, within Within of
+// each other if that is set, and every one of Require holds.  Cumulative
+// achievements keep counting across games.  Mode limits an achievement to
+// games played in that mode.  The built in achievements leave Name and
+// Description empty and take them from the message catalog.
+type Achievement struct {
+	ID          string
+	Name        string
+	Description string
+	On          []string
+	Amount      string
+	Count       int
+	Within      time.Duration
+	Cumulative  bool
+	Mode        string
+	Require     []Condition
+}
+
+// Condition compares a field of the record, or failing that a game stat,
+// with Value.  The stats are accuracy (in percent), whacks, hits, misses,
+// whiffs, score, streak, ticks and elapsed (in seconds).
+type Condition struct {
+	Stat  string  `json:"stat"`
+	Op    string  `json:"op"`
+	Value float64 `json:"value"`
+}
+
+func DefaultAchievements() []Achievement {
+	return []Achievement{
+		{ID: "sharpshooter", On: []string{"game.won"}, Require: []Condition{{Stat: "accuracy", Op: ">=", Value: 100}}},
+		{ID: "hat_trick", On: []string{"whack.hit", "whack.sweep"}, Amount: "hits", Count: 3, Within: 2 * time.Second},
+		{ID: "clean_sweep", On: []string{"game.won"}, Require: []Condition{{Stat: "whiffs", Op: "<=", Value: 0}}},
+		{ID: "survivor", On: []string{"survival.minute"}, Mode: "survival", Require: []Condition{{Stat: "minutes", Op: ">=", Value: 10}}},
+		{ID: "exterminator", On: []string{"whack.hit", "whack.sweep"}, Amount: "hits", Count: 100, Cumulative: true},
+	}
+}
+
+type jsonAchievement struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	On          []string    `json:"on"`
+	Amount      string      `json:"amount"`
+	Count       int         `json:"count"`
+	Within      string      `json:"within"`
+	Cumulative  bool        `json:"cumulative"`
+	Mode        string      `json:"mode"`
+	Require     []Condition `json:"require"`
+}
+
+// LoadAchievements reads a JSON list of achievements, such as
+// [{"id": "quick", "on": ["whack.hit"], "count": 2, "within": "1s"}].
+func LoadAchievements(r io.Reader) ([]Achievement, error) {
+	var js []jsonAchievement
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&js); err != nil {
+		return nil, err
+	}
+	var defs []Achievement
+	seen := make(map[string]bool)
+	for _, j := range js {
+		if j.ID == "" || len(j.On) == 0 {
+			return nil, fmt.Errorf("achievement %q needs an id and at least one event", j.ID)
+		}
+		if seen[j.ID] {
+			return nil, fmt.Errorf("achievement %q defined twice", j.ID)
+		}
+		seen[j.ID] = true
+		a := Achievement{ID: j.ID, Name: j.Name, Description: j.Description, On: j.On, Amount: j.Amount, Count: j.Count, Cumulative: j.Cumulative, Mode: j.Mode, Require: j.Require}
+		if a.Name == "" {
+			a.Name = a.ID
+		}
+		if j.Within != "" {
+			d, err := time.ParseDuration(j.Within)
+			if err != nil {
+				return nil, fmt.Errorf("achievement %q: bad within %q", j.ID, j.Within)
+			}
+			a.Within = d
+		}
+		for _, c := range a.Require {
+			if _, ok := compare(c.Op, 0, 0); !ok {
+				return nil, fmt.Errorf("achievement %q: bad op %q", j.ID, c.Op)
+			}
+		}
+		defs = append(defs, a)
+	}
+	return defs, nil
+}
+
+func compare(op string, a, b float64) (result bool, ok bool) {
+	switch op {
+	case ">=":
+		return a >= b, true
+	case "<=":
+		return a <= b, true
+	case ">":
+		return a > b, true
+	case "<":
+		return a < b, true
+	case "==":
+		return a == b, true
+	}
+	return false, false
+}
+
+// PlayerAchievements is what one player has unlocked, and how far they got
+// towards the rest.
+type PlayerAchievements struct {
+	Unlocked map[string]time.Time `json:"unlocked"`
+	Progress map[string]int       `json:"progress"`
+}
+
+// AchievementStore keeps every player's achievements in a JSON file.
+type AchievementStore struct {
+	Players map[string]*PlayerAchievements `json:"players"`
+}
+
+func DefaultAchievementPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "wam", "achievements.json")
+}
+
+// LoadAchievementStore reads the store at path.  A missing file is an
+// empty store.
+func LoadAchievementStore(path string) (*AchievementStore, error) {
+	s := &AchievementStore{Players: make(map[string]*PlayerAchievements)}
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if s.Players == nil {
+		s.Players = make(map[string]*PlayerAchievements)
+	}
+	return s, nil
+}
+
+// Save writes the store to a temporary file first so a crash can't leave
+// half a file behind.
+func (s *AchievementStore) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(b, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (s *AchievementStore) Player(name string) *PlayerAchievements {
+	p := s.Players[name]
+	if p == nil {
+		p = &PlayerAchievements{}
+		s.Players[name] = p
+	}
+	if p.Unlocked == nil {
+		p.Unlocked = make(map[string]time.Time)
+	}
+	if p.Progress == nil {
+		p.Progress = make(map[string]int)
+	}
+	return p
+}
+
+// Achievements watches the records of a game on behalf of one player.
+type Achievements struct {
+	Defs   []Achievement
+	Player *PlayerAchievements
+	counts map[string]int
+	times  map[string][]time.Duration
+}
+
+func NewAchievements(defs []Achievement, p *PlayerAchievements) *Achievements {
+	return &Achievements{Defs: defs, Player: p, counts: make(map[string]int), times: make(map[string][]time.Duration)}
+}
+
+// observe checks r against every achievement still locked.
+func (g *Game) observe(r Record) {
+	a := g.Achievements
+	if a == nil || r.Key == "achievement.unlocked" {
+		return
+	}
+	p := a.Player
+	for _, def := range a.Defs {
+		if _, ok := p.Unlocked[def.ID]; ok || !slices.Contains(def.On, r.Key) {
+			continue
+		}
+		if def.Mode != "" && def.Mode != g.Config.Mode {
+			continue
+		}
+		amount := 1
+		if def.Amount != "" {
+			if v, ok := r.Fields[def.Amount].(int); ok {
+				amount = v
+			}
+		}
+		if amount <= 0 {
+			continue
+		}
+		var count int
+		switch {
+		case def.Cumulative:
+			p.Progress[def.ID] += amount
+			count = p.Progress[def.ID]
+		case def.Within > 0:
+			ts := a.times[def.ID]
+			for range amount {
+				ts = append(ts, r.At)
+			}
+			for len(ts) > 0 && r.At-ts[0] > def.Within {
+				ts = ts[1:]
+			}
+			a.times[def.ID] = ts
+			count = len(ts)
+		default:
+			a.counts[def.ID] += amount
+			count = a.counts[def.ID]
+		}
+		// Progress only means something for achievements that take more
+		// than one record; the rest are either unlocked or not.
+		if !def.Cumulative && def.Count > 1 {
+			p.Progress[def.ID] = max(p.Progress[def.ID], min(count, def.Count))
+		}
+		if count < def.Count || !g.meets(def.Require, r) {
+			continue
+		}
+		p.Unlocked[def.ID] = g.Clock.Now()
+		name, description := def.label()
+		g.emit("achievement.unlocked", Fields{"name": name, "description": description})
+	}
+}
+
+// label gives the name and description to show for the achievement:
+// translated for the built in ones, as written for ones loaded from
+// -achievement-defs.
+func (a Achievement) label() (name, description any) {
+	if a.Name == "" {
+		return Text{Key: "achievement." + a.ID + ".name"}, Text{Key: "achievement." + a.ID + ".description"}
+	}
+	return a.Name, a.Description
+}
+
+func (g *Game) meets(conds []Condition, r Record) bool {
+	for _, c := range conds {
+		ok, _ := compare(c.Op, g.statValue(c.Stat, r), c.Value)
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *Game) statValue(name string, r Record) float64 {
+	switch v := r.Fields[name].(type) {
+	case int:
+		return float64(v)
+	case float64:
+		return v
+	case time.Duration:
+		return v.Seconds()
+	}
+	switch name {
+	case "accuracy":
+		return 100 * g.Stats.Accuracy()
+	case "whacks":
+		return float64(g.Stats.Whacks)
+	case "hits":
+		return float64(g.Stats.Hits)
+	case "misses":
+		return float64(g.Stats.Misses)
+	case "whiffs":
+		return float64(g.Stats.Whiffs)
+	case "score":
+		return float64(g.Combo.Score)
+	case "streak":
+		return float64(g.Combo.Streak)
+	case "ticks":
+		return float64(g.Ticks)
+	case "elapsed":
+		return g.Elapsed().Seconds()
+	}
+	return 0
+}
+
+func (g *Game) handleAchievements() {
+	a := g.Achievements
+	if a == nil {
+		g.respond("achievements.off", nil)
+		return
+	}
+	var items []Fields
+	for _, def := range a.Defs {
+		var status any = fmt.Sprintf("%d/%d", a.Player.Progress[def.ID], def.Count)
+		if at, ok := a.Player.Unlocked[def.ID]; ok {
+			status = Text{Key: "word.unlocked", Fields: Fields{"date": at.Format("2006-01-02")}}
+		} else if def.Count <= 1 {
+			status = word("locked")
+		}
+		name, description := def.label()
+		items = append(items, Fields{"name": name, "description": description, "status": status})
+	}
+	g.respondList("achievements", Fields{"unlocked": len(a.Player.Unlocked), "total": len(a.Defs)}, items)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withAchievements plays by the combo rules for p, keeping the built in
+// achievements.
+func withAchievements(p *PlayerAchievements) func(g *Game) {
+	return func(g *Game) {
+		comboRules(g)
+		g.Achievements = NewAchievements(DefaultAchievements(), p)
+	}
+}
+
+func TestAchievementWithin(t *testing.T) {
+	store := &AchievementStore{Players: map[string]*PlayerAchievements{}}
+	g, clock, buf := newTestGame(5, 5, withAchievements(store.Player("ann")))
+	exposeAll(g)
+	g.ProcessPlayerInput("whack 1")
+	clock.Advance(1500 * time.Millisecond)
+	g.ProcessPlayerInput("whack 2")
+	clock.Advance(1500 * time.Millisecond)
+	g.ProcessPlayerInput("whack 3")
+	assert.NotContains(t, buf.String(), "Hat trick")
+	assert.Equal(t, 2, g.Achievements.Player.Progress["hat_trick"])
+	clock.Advance(500 * time.Millisecond)
+	g.ProcessPlayerInput("whack 4")
+	assert.Contains(t, buf.String(), "*** Achievement unlocked: Hat trick, Whack 3 moles within 2 seconds! ***\n")
+	assert.Contains(t, g.Achievements.Player.Unlocked, "hat_trick")
+}
+
+func TestAchievementOnWin(t *testing.T) {
+	store := &AchievementStore{Players: map[string]*PlayerAchievements{}}
+	g, _, buf := newTestGame(2, 2, withAchievements(store.Player("ann")))
+	exposeAll(g)
+	g.ProcessPlayerInput("whack 1")
+	g.ProcessPlayerInput("whack 2")
+	assert.Contains(t, buf.String(), "Achievement unlocked: Sharpshooter")
+	assert.Contains(t, buf.String(), "Achievement unlocked: Clean sweep")
+
+	g, _, buf = newTestGame(2, 2, withAchievements(store.Player("bob")))
+	exposeAll(g)
+	g.ProcessPlayerInput("whack 1")
+	g.ProcessPlayerInput("whack 1")
+	g.ProcessPlayerInput("whack 2")
+	assert.Contains(t, buf.String(), "Moles eliminated")
+	assert.NotContains(t, buf.String(), "Achievement unlocked")
+	g.ProcessPlayerInput("achievements")
+	assert.Contains(t, buf.String(), "  Sharpshooter: Clear a board with 100% accuracy (locked)\n", "a win that misses the condition isn't progress")
+	assert.NotContains(t, g.Achievements.Player.Progress, "sharpshooter")
+}
+
+func TestAchievementsKeptAcrossGames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wam", "achievements.json")
+	store, err := LoadAchievementStore(path)
+	require.NoError(t, err)
+	g, _, _ := newTestGame(2, 2, withAchievements(store.Player("ann")))
+	exposeAll(g)
+	g.ProcessPlayerInput("whack 1")
+	require.NoError(t, store.Save(path))
+
+	store, err = LoadAchievementStore(path)
+	require.NoError(t, err)
+	g, _, buf := newTestGame(2, 2, withAchievements(store.Player("ann")))
+	exposeAll(g)
+	g.ProcessPlayerInput("whack 1")
+	g.ProcessPlayerInput("achievements")
+	assert.Contains(t, buf.String(), "0 of 5 achievements unlocked:\n")
+	assert.Contains(t, buf.String(), "  Exterminator: Whack 100 moles (2/100)\n")
+	assert.Contains(t, buf.String(), "  Hat trick: Whack 3 moles within 2 seconds (1/3)\n")
+
+	g.ProcessPlayerInput("whack 2")
+	buf.Reset()
+	g.ProcessPlayerInput("achievements")
+	assert.Contains(t, buf.String(), "2 of 5 achievements unlocked:\n")
+	assert.Contains(t, buf.String(), "  Sharpshooter: Clear a board with 100% accuracy (unlocked 1970-01-01)\n")
+}
+
+func TestAchievementsTranslated(t *testing.T) {
+	es, err := NewCatalog("es")
+	require.NoError(t, err)
+	defs, err := LoadAchievements(strings.NewReader(`[{"id": "quick", "name": "Quick hands", "description": "Whack 2 moles", "on": ["whack.hit"], "count": 2}]`))
+	require.NoError(t, err)
+	store := &AchievementStore{Players: map[string]*PlayerAchievements{}}
+	g, _, _ := newTestGame(2, 2, withAchievements(store.Player("ann")))
+	exposeAll(g)
+	g.Achievements.Defs = append(g.Achievements.Defs, defs...)
+	var buf bytes.Buffer
+	g.Renderer = &TextRenderer{Out: &buf, Catalog: es}
+	g.ProcessPlayerInput("whack 1")
+	g.ProcessPlayerInput("achievements")
+	assert.Contains(t, buf.String(), "  Exterminador: Golpea 100 topos (1/100)\n")
+	assert.Contains(t, buf.String(), "  Quick hands: Whack 2 moles (1/2)\n", "the player's own achievements are shown as written")
+	g.ProcessPlayerInput("whack 2")
+	assert.Contains(t, buf.String(), "*** Logro desbloqueado: Francotirador, ¡Limpia un tablero con un 100% de precisión! ***\n")
+	assert.Contains(t, buf.String(), "*** Logro desbloqueado: Quick hands, ¡Whack 2 moles! ***\n")
+}
+
+func TestSurvivalMode(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	clock := NewManualClock(time.Unix(0, 0))
+	g.Clock = clock
+	g.Config.Entropy = 0
+	require.NoError(t, g.Config.ApplyMode("survival", 4))
+	assert.Equal(t, Breeding{Rate: 0.05, Overrun: 8}, g.Config.Breeding)
+	store := &AchievementStore{Players: map[string]*PlayerAchievements{}}
+	g.Achievements = NewAchievements(DefaultAchievements(), store.Player("ann"))
+	g.Config.Breeding.Rate = 0
+	g.Init(4, 1)
+	for range 10 {
+		clock.Advance(time.Minute)
+		g.ProcessTick()
+	}
+	assert.Contains(t, buf.String(), "You've survived 1 minutes!\n")
+	assert.Contains(t, buf.String(), "Achievement unlocked: Survivor")
+	assert.EqualError(t, g.Config.ApplyMode("zen", 4), `unknown mode "zen", pick one of classic, survival`)
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-mode", "survival", "-time-limit", "1m"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, ExitError, code)
+	assert.Equal(t, "survival mode can't have a time limit\n", stderr.String())
+}
+
+func TestLoadAchievements(t *testing.T) {
+	defs, err := LoadAchievements(strings.NewReader(`[{"id": "quick", "on": ["whack.hit"], "count": 2, "within": "1s", "require": [{"stat": "score", "op": ">", "value": 50}]}]`))
+	require.NoError(t, err)
+	assert.Equal(t, []Achievement{{ID: "quick", Name: "quick", On: []string{"whack.hit"}, Count: 2, Within: time.Second, Require: []Condition{{Stat: "score", Op: ">", Value: 50}}}}, defs)
+
+	_, err = LoadAchievements(strings.NewReader(`[{"id": "quick", "on": ["whack.hit"], "within": "soon"}]`))
+	assert.EqualError(t, err, `achievement "quick": bad within "soon"`)
+	_, err = LoadAchievements(strings.NewReader(`[{"id": "quick", "on": ["whack.hit"], "require": [{"stat": "score", "op": "~", "value": 1}]}]`))
+	assert.EqualError(t, err, `achievement "quick": bad op "~"`)
+	_, err = LoadAchievements(strings.NewReader(`[{"id": "quick"}]`))
+	assert.Error(t, err)
+}
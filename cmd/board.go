@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// BoardEvents shake up the whole board now and then.  Each tick there is a
+// Chance of an event, at least MinGap ticks after the last one, and Table
+// says which events can happen and how likely each is.  An earthquake sends
+// every housed mole off to another hole, a flood caves in a row of holes
+// for Ticks, a frenzy brings every mole up at once and fog hides moles
+// appearing for Ticks unless the radar is on them.
+type BoardEvents struct {
+	Chance float64
+	MinGap int
+	Table  []BoardEventRule
+}
+
+type BoardEventRule struct {
+	Event  string  `json:"event"`
+	Weight float64 `json:"weight"`
+	Ticks  int     `json:"ticks"`
+}
+
+var BoardEventNames = []string{"earthquake", "flood", "frenzy", "fog"}
+
+func DefaultBoardEvents() BoardEvents {
+	return BoardEvents{
+		MinGap: 10,
+		Table: []BoardEventRule{
+			{Event: "earthquake", Weight: 1},
+			{Event: "flood", Weight: 1, Ticks: 5},
+			{Event: "frenzy", Weight: 1},
+			{Event: "fog", Weight: 1, Ticks: 5},
+		},
+	}
+}
+
+type jsonBoardEvents struct {
+	Chance *float64          `json:"chance"`
+	MinGap *int              `json:"min_gap"`
+	Table  *[]BoardEventRule `json:"table"`
+}
+
+// LoadBoardEvents reads a schedule written as JSON, such as
+// {"chance": 0.05, "table": [{"event": "flood", "weight": 2, "ticks": 3}]},
+// over the top of base.
+func LoadBoardEvents(r io.Reader, base BoardEvents) (BoardEvents, error) {
+	var j jsonBoardEvents
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&j); err != nil {
+		return base, err
+	}
+	b := base
+	if j.Chance != nil {
+		b.Chance = *j.Chance
+	}
+	if j.MinGap != nil {
+		b.MinGap = *j.MinGap
+	}
+	if j.Table != nil {
+		for _, rule := range *j.Table {
+			known := false
+			for _, name := range BoardEventNames {
+				known = known || name == rule.Event
+			}
+			if !known || rule.Weight < 0 || rule.Ticks < 0 {
+				return base, fmt.Errorf("bad board event %q", rule.Event)
+			}
+		}
+		b.Table = *j.Table
+	}
+	if b.Chance < 0 || b.Chance > 1 {
+		return base, fmt.Errorf("chance must be between 0 and 1")
+	}
+	return b, nil
+}
+
+// ProcessBoardEvents rolls for an event and lifts any fog that has run its
+// course.
+func (g *Game) ProcessBoardEvents() {
+	b := g.Config.BoardEvents
+	if g.FogUntil > 0 && g.Ticks >= g.FogUntil {
+		g.FogUntil = 0
+		g.emit("board.fog_lifted", nil)
+	}
+	if b.Chance <= 0 || g.State == End {
+		return
+	}
+	if g.LastBoardEvent > 0 && g.Ticks-g.LastBoardEvent < b.MinGap {
+		return
+	}
+	if g.Rand.Float64() >= b.Chance {
+		return
+	}
+	total := 0.0
+	for _, rule := range b.Table {
+		total += rule.Weight
+	}
+	if total <= 0 {
+		return
+	}
+	roll := g.Rand.Float64() * total
+	for _, rule := range b.Table {
+		if roll < rule.Weight {
+			g.LastBoardEvent = g.Ticks
+			g.BoardEvent(rule)
+			return
+		}
+		roll -= rule.Weight
+	}
+}
+
+// BoardEvent sets off a single event.
+func (g *Game) BoardEvent(rule BoardEventRule) {
+	hs := &g.HoleFactory.HoleSet
+	ms := &g.MoleFactory.MoleSet
+	switch rule.Event {
+	case "earthquake":
+		var moles []*Mole
+		for _, m := range ms.Index.Moles {
+			if _, ok := ms.Housed[m.ID]; ok {
+				moles = append(moles, m)
+			}
+		}
+		g.emit("board.earthquake", Fields{"moles": len(moles)})
+		for _, m := range moles {
+			m.Tunnel(hs)
+			if m.HoleOccupied != nil {
+				m.Node = m.HoleOccupied.ID
+			}
+		}
+	case "flood":
+		holes := len(hs.Index.Holes)
+		width := gridWidth(holes)
+		rows := (holes + width - 1) / width
+		row := g.Rand.Intn(rows)
+		g.emit("board.flood", Fields{"row": row + 1, "ticks": rule.Ticks})
+		for _, h := range hs.Index.Holes[row*width : min((row+1)*width, holes)] {
+			if h.State == Unoccupied || h.State == Occupied {
+				g.CollapseHole(h, rule.Ticks)
+			}
+		}
+	case "frenzy":
+		var exposed []*Mole
+		for _, m := range ms.Index.Moles {
+			if _, ok := ms.Housed[m.ID]; ok && m.State == HidingAlive {
+				m.ToggleState()
+				exposed = append(exposed, m)
+			}
+		}
+		g.emit("board.frenzy", Fields{"moles": len(exposed)})
+		for _, m := range exposed {
+			if g.visible(m.HoleOccupied.ID) {
+				key := "mole.appeared"
+				if m.Armored {
+					key = "mole.appeared_armored"
+				}
+				g.emit(key, Fields{"mole": m.ID, "hole": m.HoleOccupied.ID})
+			}
+		}
+	case "fog":
+		g.FogUntil = g.Ticks + rule.Ticks
+		g.emit("board.fog", Fields{"ticks": rule.Ticks})
+	}
+}
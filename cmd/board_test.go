@@ -0,0 +1,90 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEarthquake(t *testing.T) {
+	g, _, buf := newTestGame(6, 3, boardRules)
+	g.Config.Selection = "uniform"
+	g.HoleFactory.HoleSet.Index.Selector, _ = NewHoleSelector("uniform", g.Rand)
+	g.BoardEvent(BoardEventRule{Event: "earthquake"})
+	assert.Contains(t, buf.String(), "EARTHQUAKE! The ground shakes and 3 moles scramble for new holes!\n")
+	assert.Len(t, g.MoleFactory.MoleSet.Housed, 3)
+	var holes []int
+	for _, m := range g.MoleFactory.MoleSet.Index.Moles {
+		holes = append(holes, m.HoleOccupied.ID)
+	}
+	assert.NotEqual(t, []int{1, 2, 3}, holes)
+}
+
+func TestFlood(t *testing.T) {
+	g, _, buf := newTestGame(9, 1, boardRules)
+	g.BoardEvent(BoardEventRule{Event: "flood", Ticks: 2})
+	out := buf.String()
+	require.Contains(t, out, "FLOOD! Row ")
+	row := int(out[strings.Index(out, "Row ")+4] - '0')
+	for id := 1; id <= 9; id++ {
+		flooded := (id-1)/3 == row-1
+		assert.Equal(t, flooded, g.HoleFactory.HoleSet.GetHole(id).State == Collapsed, "hole %d", id)
+	}
+	assert.Len(t, g.MoleFactory.MoleSet.Housed, 1)
+	g.ProcessTick()
+	g.ProcessTick()
+	assert.Empty(t, g.HoleFactory.HoleSet.Collapsed)
+}
+
+func TestFrenzyAndFog(t *testing.T) {
+	g, _, buf := newTestGame(4, 4, boardRules)
+	g.MoleFactory.MoleSet.Housed[2].ToggleState()
+	g.BoardEvent(BoardEventRule{Event: "fog", Ticks: 2})
+	g.BoardEvent(BoardEventRule{Event: "frenzy"})
+	assert.Contains(t, buf.String(), "Fog rolls in, you won't see moles come up for 2 ticks.\n")
+	assert.Contains(t, buf.String(), "MOLE FRENZY! 3 moles pop up at once!\n")
+	assert.NotContains(t, buf.String(), "appeared")
+	for _, m := range g.MoleFactory.MoleSet.Housed {
+		assert.Equal(t, ExposedAlive, m.State)
+	}
+	g.ProcessTick()
+	assert.NotContains(t, buf.String(), "The fog lifts.")
+	g.ProcessTick()
+	assert.Contains(t, buf.String(), "The fog lifts.\n")
+	assert.True(t, g.visible(1))
+}
+
+func TestBoardEventSchedule(t *testing.T) {
+	g, _, buf := newTestGame(4, 1, boardRules)
+	g.Config.BoardEvents = BoardEvents{Chance: 1, MinGap: 3, Table: []BoardEventRule{{Event: "frenzy", Weight: 1}, {Event: "earthquake", Weight: 0}}}
+	for range 7 {
+		g.ProcessTick()
+	}
+	assert.Equal(t, 3, strings.Count(buf.String(), "MOLE FRENZY!"))
+	assert.Equal(t, 7, g.LastBoardEvent)
+
+	// Board events get through however many mole events there are.
+	g, _, buf = newTestGame(4, 4, boardRules)
+	g.Config.EventLimit = 1
+	g.Config.BoardEvents = BoardEvents{Chance: 1, Table: []BoardEventRule{{Event: "frenzy", Weight: 1}}}
+	g.ProcessTick()
+	assert.Contains(t, buf.String(), "MOLE FRENZY! 4 moles pop up at once!\n")
+	assert.Contains(t, buf.String(), "...and 3 more things happened")
+}
+
+func TestLoadBoardEvents(t *testing.T) {
+	b, err := LoadBoardEvents(strings.NewReader(`{"chance": 0.05, "table": [{"event": "flood", "weight": 2, "ticks": 3}]}`), DefaultBoardEvents())
+	require.NoError(t, err)
+	assert.Equal(t, BoardEvents{Chance: 0.05, MinGap: 10, Table: []BoardEventRule{{Event: "flood", Weight: 2, Ticks: 3}}}, b)
+	_, err = LoadBoardEvents(strings.NewReader(`{"table": [{"event": "volcano", "weight": 1}]}`), DefaultBoardEvents())
+	assert.EqualError(t, err, `bad board event "volcano"`)
+	_, err = LoadBoardEvents(strings.NewReader(`{"chance": 2}`), DefaultBoardEvents())
+	assert.Error(t, err)
+}
+
+func boardRules(g *Game) {
+	g.Config.Entropy = 0
+	g.SetSeed(7)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// HoleView is what a player can tell about one hole from the game output.
+// Occupied is only set when the player can see the mole, and Closed marks
+// holes that have caved in or been plugged.
+type HoleView struct {
+	ID       int  `json:"id"`
+	Occupied bool `json:"occupied"`
+	Exposed  bool `json:"exposed"`
+	Closed   bool `json:"closed,omitempty"`
+}
+
+// Observation is the board as a player sees it.  Moles only give themselves
+// away when they are exposed or caught by a peek, so hiding moles otherwise
+// look like empty holes, and under fog so do exposed ones.
+type Observation struct {
+	Tick      int        `json:"tick"`
+	Holes     []HoleView `json:"holes"`
+	MolesLeft int        `json:"moles_left"`
+}
+
+func (o Observation) ExposedHoles() []int {
+	var ids []int
+	for _, h := range o.Holes {
+		if h.Exposed {
+			ids = append(ids, h.ID)
+		}
+	}
+	return ids
+}
+
+func (g *Game) Observe() Observation {
+	hs := &g.HoleFactory.HoleSet
+	obs := Observation{
+		Tick:      g.Ticks,
+		Holes:     make([]HoleView, 0, len(hs.Available)+len(hs.Unavailable)),
+		MolesLeft: g.WinCondition - len(g.MoleFactory.MoleSet.Dead),
+	}
+	for _, h := range hs.Available {
+		obs.Holes = append(obs.Holes, HoleView{ID: h.ID})
+	}
+	for _, h := range hs.Unavailable {
+		if !g.visible(h.ID) {
+			obs.Holes = append(obs.Holes, HoleView{ID: h.ID})
+			continue
+		}
+		status := holeStatus(h)
+		peeked := g.Radar != nil && g.Radar.Reveals(g.Ticks, h.ID)
+		obs.Holes = append(obs.Holes, HoleView{
+			ID:       h.ID,
+			Occupied: status == "exposed" || (peeked && status == "hiding"),
+			Exposed:  status == "exposed",
+		})
+	}
+	for _, h := range sortedHoles(hs.Collapsed, hs.Blocked) {
+		obs.Holes = append(obs.Holes, HoleView{ID: h.ID, Closed: true})
+	}
+	sort.Slice(obs.Holes, func(i, j int) bool { return obs.Holes[i].ID < obs.Holes[j].ID })
+	return obs
+}
+
+// Bot is an automated player.  Act is called whenever the bot gets a chance
+// to type and returns the command to run, or "" to wait.
+type Bot interface {
+	Act(obs Observation) string
+}
+
+type idleBot struct{}
+
+func (idleBot) Act(Observation) string {
+	return ""
+}
+
+type randomBot struct {
+	rng *rand.Rand
+}
+
+func (b *randomBot) Act(obs Observation) string {
+	if len(obs.Holes) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("whack %d", obs.Holes[b.rng.Intn(len(obs.Holes))].ID)
+}
+
+type greedyBot struct {
+	rng *rand.Rand
+}
+
+func (b *greedyBot) Act(obs Observation) string {
+	exposed := obs.ExposedHoles()
+	if len(exposed) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("whack %d", exposed[b.rng.Intn(len(exposed))])
+}
+
+// sloppyBot goes for exposed moles like greedyBot but only notices them some
+// of the time and sometimes swings at the wrong hole.
+type sloppyBot struct {
+	rng    *rand.Rand
+	greedy greedyBot
+	random randomBot
+}
+
+func (b *sloppyBot) Act(obs Observation) string {
+	switch r := b.rng.Intn(100); {
+	case r < 50:
+		return b.greedy.Act(obs)
+	case r < 70:
+		return b.random.Act(obs)
+	default:
+		return ""
+	}
+}
+
+var Bots = map[string]func(rng *rand.Rand) Bot{
+	"idle": func(*rand.Rand) Bot {
+		return idleBot{}
+	},
+	"random": func(rng *rand.Rand) Bot {
+		return &randomBot{rng: rng}
+	},
+	"greedy": func(rng *rand.Rand) Bot {
+		return &greedyBot{rng: rng}
+	},
+	"sloppy": func(rng *rand.Rand) Bot {
+		return &sloppyBot{rng: rng, greedy: greedyBot{rng: rng}, random: randomBot{rng: rng}}
+	},
+}
+
+func NewBot(name string, rng *rand.Rand) (Bot, error) {
+	newBot, ok := Bots[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown bot %q", name)
+	}
+	return newBot(rng), nil
+}
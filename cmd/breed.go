@@ -0,0 +1,66 @@
+package main
+
+// Breeding lets surviving moles have pups.  Each tick every living mole has
+// a Rate chance of a pup, as long as fewer than Cap moles are alive.  Once
+// more than Overrun moles are alive the player loses.  Zero turns each part
+// off.
+type Breeding struct {
+	Rate    float64
+	Cap     int
+	Overrun int
+}
+
+func (g *Game) aliveMoles() int {
+	ms := &g.MoleFactory.MoleSet
+	return len(ms.Housed) + len(ms.Unhoused)
+}
+
+// updateWinCondition keeps the win condition at every mole that has ever
+// lived, so a player wins by clearing pups as well as the starting moles.
+func (g *Game) updateWinCondition() {
+	ms := &g.MoleFactory.MoleSet
+	g.WinCondition = len(ms.Housed) + len(ms.Unhoused) + len(ms.Dead)
+}
+
+func (g *Game) BreedMoles() {
+	b := g.Config.Breeding
+	if g.State == End {
+		return
+	}
+	if b.Rate > 0 {
+		ms := &g.MoleFactory.MoleSet
+		var parents []*Mole
+		for _, m := range ms.Index.Moles {
+			if _, ok := ms.Housed[m.ID]; ok {
+				parents = append(parents, m)
+			}
+		}
+		for _, m := range ms.Index.Moles {
+			if _, ok := ms.Unhoused[m.ID]; ok {
+				parents = append(parents, m)
+			}
+		}
+		for _, parent := range parents {
+			if b.Cap > 0 && g.aliveMoles() >= b.Cap {
+				break
+			}
+			if g.Rand.Float64() >= b.Rate {
+				continue
+			}
+			pup, err := g.MoleFactory.NewMole()
+			if err != nil {
+				continue
+			}
+			f := Fields{"mole": pup.ID, "parent": parent.ID}
+			if pup.TryOccupy(&g.HoleFactory.HoleSet) {
+				f["hole"] = pup.HoleOccupied.ID
+			}
+			g.emit("mole.born", f)
+		}
+		g.updateWinCondition()
+	}
+	if b.Overrun > 0 && g.aliveMoles() > b.Overrun {
+		g.emit("game.overrun", Fields{"alive": g.aliveMoles()})
+		g.end(Lost)
+	}
+}
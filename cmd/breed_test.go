@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWhackFreesHole(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.Init(3, 3)
+	m := g.MoleFactory.MoleSet.Housed[1]
+	h := m.HoleOccupied
+	m.ToggleState()
+	assert.True(t, m.TryWhack())
+	assert.Nil(t, m.HoleOccupied)
+	assert.Nil(t, h.OccupyingMole)
+	assert.Equal(t, Unoccupied, h.State)
+	assert.Equal(t, h, g.HoleFactory.HoleSet.Available[1])
+}
+
+func TestBreeding(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.Config.Entropy = 0
+	g.Config.Breeding = Breeding{Rate: 1, Cap: 5}
+	g.Init(4, 2)
+
+	g.ProcessTick()
+	assert.Equal(t, 4, g.aliveMoles())
+	assert.Equal(t, 4, g.WinCondition)
+	assert.Len(t, g.MoleFactory.MoleSet.Housed, 4)
+	assert.Contains(t, buf.String(), "mole 1 had a pup, mole 3 is loose!")
+
+	g.ProcessTick()
+	assert.Equal(t, 5, g.aliveMoles())
+	assert.Len(t, g.MoleFactory.MoleSet.Unhoused, 1)
+
+	buf.Reset()
+	g.ProcessPlayerInput("moles")
+	assert.Contains(t, buf.String(), "Alive: 5\nDead: 0\nTo win: 5 dead\n")
+
+	for _, m := range g.MoleFactory.MoleSet.Housed {
+		m.ToggleState()
+		m.TryWhack()
+	}
+	g.Config.Breeding.Rate = 0
+	g.MoleFactory.MoleSet.Unhoused[5].Tunnel(&g.HoleFactory.HoleSet)
+	g.MoleFactory.MoleSet.Housed[5].ToggleState()
+	g.ProcessPlayerInput("whack 1")
+	assert.Equal(t, Won, g.Outcome)
+}
+
+func TestOverrun(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.Config.Entropy = 0
+	g.Config.Breeding = Breeding{Rate: 1, Overrun: 6}
+	g.Init(10, 2)
+
+	g.ProcessTick()
+	assert.Equal(t, Undecided, g.Outcome)
+	g.ProcessTick()
+	assert.Equal(t, Lost, g.Outcome)
+	assert.Contains(t, buf.String(), "8 moles! They've overrun the garden")
+
+	buf.Reset()
+	g.ProcessPlayerInput("moles")
+	assert.Contains(t, buf.String(), "Overrun: more than 6 alive")
+}
@@ -0,0 +1,37 @@
+package main
+
+import "time"
+
+// Clock is the game's source of time. Interactive play uses the wall clock,
+// scripted runs drive a ManualClock so results do not depend on the machine.
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+type ManualClock struct {
+	now time.Time
+}
+
+func NewManualClock(start time.Time) *ManualClock {
+	return &ManualClock{now: start}
+}
+
+func (c *ManualClock) Now() time.Time {
+	return c.now
+}
+
+func (c *ManualClock) Set(t time.Time) {
+	if t.After(c.now) {
+		c.now = t
+	}
+}
+
+func (c *ManualClock) Advance(d time.Duration) {
+	c.Set(c.now.Add(d))
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// ScoreRules decide what a hit is worth.  Each hit within Window of the one
+// before it extends the streak and raises the multiplier by Step, up to
+// MaxMultiplier.  When a streak reaches FrenzyStreak hits the player gets
+// FrenzyBonus points on top, and a swing that hits nothing ends the streak.
+type ScoreRules struct {
+	HitPoints     int
+	Window        time.Duration
+	Step          float64
+	MaxMultiplier float64
+	FrenzyStreak  int
+	FrenzyBonus   int
+}
+
+func DefaultScoreRules() ScoreRules {
+	return ScoreRules{
+		HitPoints:     100,
+		Window:        2 * time.Second,
+		Step:          0.5,
+		MaxMultiplier: 4,
+		FrenzyStreak:  5,
+		FrenzyBonus:   500,
+	}
+}
+
+type jsonScoreRules struct {
+	HitPoints     *int     `json:"hit_points"`
+	Window        *string  `json:"window"`
+	Step          *float64 `json:"step"`
+	MaxMultiplier *float64 `json:"max_multiplier"`
+	FrenzyStreak  *int     `json:"frenzy_streak"`
+	FrenzyBonus   *int     `json:"frenzy_bonus"`
+}
+
+// LoadScoreRules reads rules written as JSON, such as
+// {"window": "1.5s", "frenzy_streak": 3}, over the top of base.
+func LoadScoreRules(r io.Reader, base ScoreRules) (ScoreRules, error) {
+	var j jsonScoreRules
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&j); err != nil {
+		return base, err
+	}
+	rules := base
+	if j.HitPoints != nil {
+		rules.HitPoints = *j.HitPoints
+	}
+	if j.Window != nil {
+		d, err := time.ParseDuration(*j.Window)
+		if err != nil {
+			return base, fmt.Errorf("bad window %q", *j.Window)
+		}
+		rules.Window = d
+	}
+	if j.Step != nil {
+		rules.Step = *j.Step
+	}
+	if j.MaxMultiplier != nil {
+		rules.MaxMultiplier = *j.MaxMultiplier
+	}
+	if j.FrenzyStreak != nil {
+		rules.FrenzyStreak = *j.FrenzyStreak
+	}
+	if j.FrenzyBonus != nil {
+		rules.FrenzyBonus = *j.FrenzyBonus
+	}
+	return rules, nil
+}
+
+type Combo struct {
+	Score         int
+	Streak        int
+	Multiplier    float64
+	LastHit       time.Duration
+	LongestStreak int
+	MaxMultiplier float64
+	Frenzies      int
+}
+
+// multiplier is what the next hit of a streak of the given length is worth.
+func (r ScoreRules) multiplier(streak int) float64 {
+	m := 1 + r.Step*float64(streak-1)
+	if r.MaxMultiplier > 0 {
+		m = math.Min(m, r.MaxMultiplier)
+	}
+	return m
+}
+
+// scoreSwing scores a swing of the hammer that bonked hits moles.
+func (g *Game) scoreSwing(hits int) {
+	c := &g.Combo
+	r := g.Config.Scoring
+	now := g.Elapsed()
+	if hits == 0 {
+		if c.Streak > 1 {
+			g.respond("combo.broken", Fields{"streak": c.Streak})
+		}
+		c.Streak = 0
+		c.Multiplier = 0
+		return
+	}
+	if c.Streak > 0 && now-c.LastHit > r.Window {
+		c.Streak = 0
+	}
+	points := 0
+	for range hits {
+		c.Streak++
+		c.Multiplier = r.multiplier(c.Streak)
+		points += int(math.Round(float64(r.HitPoints) * c.Multiplier))
+		if r.FrenzyStreak > 0 && c.Streak == r.FrenzyStreak {
+			c.Frenzies++
+			points += r.FrenzyBonus
+			g.respond("combo.frenzy", Fields{"streak": c.Streak, "bonus": r.FrenzyBonus})
+		}
+	}
+	c.LastHit = now
+	c.Score += points
+	c.LongestStreak = max(c.LongestStreak, c.Streak)
+	c.MaxMultiplier = max(c.MaxMultiplier, c.Multiplier)
+	if c.Streak > 1 {
+		g.respond("combo.streak", Fields{"streak": c.Streak, "multiplier": c.Multiplier, "points": points, "score": c.Score})
+	}
+}
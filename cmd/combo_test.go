@@ -0,0 +1,86 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func comboRules(g *Game) {
+	g.Config.Entropy = 0
+	g.Config.Scoring = ScoreRules{HitPoints: 100, Window: time.Second, Step: 0.5, MaxMultiplier: 2, FrenzyStreak: 3, FrenzyBonus: 1000}
+}
+
+// exposeAll brings every mole up so any hole can be hit.
+func exposeAll(g *Game) {
+	for _, m := range g.MoleFactory.MoleSet.Housed {
+		m.ToggleState()
+	}
+}
+
+func TestComboStreak(t *testing.T) {
+	g, clock, buf := newTestGame(6, 6, comboRules)
+	exposeAll(g)
+	g.ProcessPlayerInput("whack 1")
+	assert.Equal(t, 100, g.Combo.Score)
+	clock.Advance(500 * time.Millisecond)
+	g.ProcessPlayerInput("whack 2")
+	assert.Equal(t, 250, g.Combo.Score)
+	assert.Contains(t, buf.String(), "2 in a row! x1.5 for 150 points, 250 in total.")
+	clock.Advance(500 * time.Millisecond)
+	g.ProcessPlayerInput("whack 3")
+	assert.Equal(t, 250+200+1000, g.Combo.Score)
+	assert.Contains(t, buf.String(), "FRENZY! 3 in a row, 1000 bonus points!")
+	clock.Advance(500 * time.Millisecond)
+	g.ProcessPlayerInput("whack 4")
+	assert.Equal(t, 1650, g.Combo.Score)
+	assert.Equal(t, 2.0, g.Combo.Multiplier)
+
+	g.ProcessPlayerInput("whack 4")
+	assert.Contains(t, buf.String(), "Streak of 4 broken!")
+	assert.Zero(t, g.Combo.Streak)
+
+	g.ProcessPlayerInput("whack 5")
+	clock.Advance(2 * time.Second)
+	g.ProcessPlayerInput("whack 6")
+	assert.Equal(t, 1, g.Combo.Streak)
+	assert.Equal(t, 1850, g.Combo.Score)
+	assert.Equal(t, Combo{Score: 1850, Streak: 1, Multiplier: 1, LastHit: 3500 * time.Millisecond, LongestStreak: 4, MaxMultiplier: 2, Frenzies: 1}, g.Combo)
+}
+
+func TestComboSweep(t *testing.T) {
+	g, _, buf := newTestGame(4, 4, comboRules)
+	exposeAll(g)
+	g.Config.Hammers = []Hammer{{Name: "wide", Spread: true, SplashAccuracy: 100}}
+	g.Armory = NewArmory(g.Config.Hammers)
+	g.ProcessPlayerInput("whack 1")
+	// Holes 1, 2 and 3 form a streak of three in a single swing.
+	assert.Equal(t, 3, g.Combo.Streak)
+	assert.Equal(t, 100+150+200+1000, g.Combo.Score)
+	assert.Contains(t, buf.String(), "3 in a row! x2.0 for 1450 points")
+}
+
+func TestComboStats(t *testing.T) {
+	g, _, buf := newTestGame(2, 2, comboRules)
+	exposeAll(g)
+	g.ProcessPlayerInput("whack 1")
+	g.ProcessPlayerInput("stats")
+	assert.Contains(t, buf.String(), "Score: 100  Longest streak: 1  Best multiplier: x1.0  Frenzies: 0\n")
+}
+
+func TestLoadScoreRules(t *testing.T) {
+	rules, err := LoadScoreRules(strings.NewReader(`{"window": "1.5s", "frenzy_streak": 3}`), DefaultScoreRules())
+	require.NoError(t, err)
+	want := DefaultScoreRules()
+	want.Window = 1500 * time.Millisecond
+	want.FrenzyStreak = 3
+	assert.Equal(t, want, rules)
+
+	_, err = LoadScoreRules(strings.NewReader(`{"window": "soon"}`), DefaultScoreRules())
+	assert.EqualError(t, err, `bad window "soon"`)
+	_, err = LoadScoreRules(strings.NewReader(`{"bonus": 3}`), DefaultScoreRules())
+	assert.Error(t, err)
+}
@@ -0,0 +1,441 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DailyChallenge is the board everyone plays on a given day.  The seed and
+// the settings all come from the date, so every player gets the same game
+// and nobody can pick an easier one.
+type DailyChallenge struct {
+	Date    string
+	Seed    int64
+	Mode    string
+	Holes   int
+	Moles   int
+	Armored int
+	Entropy int
+}
+
+func NewDailyChallenge(day time.Time) DailyChallenge {
+	date := day.UTC().Format(time.DateOnly)
+	h := fnv.New64a()
+	io.WriteString(h, "wam-daily-"+date)
+	seed := int64(h.Sum64())
+	rng := rand.New(rand.NewSource(seed))
+	holes := 6 + rng.Intn(7)
+	moles := holes/2 + rng.Intn(holes/2+1)
+	return DailyChallenge{
+		Date:    date,
+		Seed:    seed,
+		Mode:    Modes[day.UTC().YearDay()%len(Modes)],
+		Holes:   holes,
+		Moles:   moles,
+		Armored: rng.Intn(moles/3 + 1),
+		Entropy: 20 + 5*rng.Intn(7),
+	}
+}
+
+// Apply sets up g for the challenge.  Call Init with the challenge's holes
+// and moles afterwards.
+func (d DailyChallenge) Apply(g *Game) {
+	debug := g.Config.Debug
+	g.Config = DefaultConfig()
+	g.Config.Debug = debug
+	g.Config.Entropy = d.Entropy
+	g.Config.ArmoredMoles = d.Armored
+	g.Config.ApplyMode(d.Mode, d.Holes)
+	if g.Config.Mode == "classic" {
+		g.Config.TimeLimit = 2 * time.Minute
+	}
+	g.SetSeed(d.Seed)
+}
+
+// Replay records a game as it is played so it can be checked later.  Ticks
+// are written as "tick" lines between the commands: a live game ticks on
+// the wall clock, so the only way to know which commands came before which
+// tick is to write the ticks down.
+type Replay struct {
+	Date    string
+	Player  string
+	Debug   bool
+	Outcome string
+	Score   int
+	Ticks   int
+	Steps   []ScriptCommand
+}
+
+func (r *Replay) add(at time.Duration, line string) {
+	if r != nil {
+		r.Steps = append(r.Steps, ScriptCommand{At: at, Line: line})
+	}
+}
+
+// Finish notes how the game went.
+func (r *Replay) Finish(g *Game) {
+	r.Outcome = g.Outcome.String()
+	r.Score = g.Combo.Score
+	r.Ticks = g.Ticks
+}
+
+// Write saves the replay in the script format, with the details of the
+// game in comments at the top.
+func (r *Replay) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# wam daily replay\n# date %s\n# player %s\n", r.Date, r.Player)
+	if r.Debug {
+		fmt.Fprintf(bw, "# debug on\n")
+	}
+	fmt.Fprintf(bw, "# result %s %d %d\n", r.Outcome, r.Score, r.Ticks)
+	for _, s := range r.Steps {
+		fmt.Fprintf(bw, "@%s %s\n", s.At, s.Line)
+	}
+	return bw.Flush()
+}
+
+func ReadReplay(rd io.Reader) (*Replay, error) {
+	b, err := io.ReadAll(rd)
+	if err != nil {
+		return nil, err
+	}
+	r := &Replay{}
+	for _, line := range strings.Split(string(b), "\n") {
+		fields := strings.Fields(strings.TrimPrefix(line, "#"))
+		if !strings.HasPrefix(line, "#") || len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "date":
+			r.Date = fields[1]
+		case "player":
+			r.Player = fields[1]
+		case "debug":
+			r.Debug = fields[1] == "on"
+		case "result":
+			if len(fields) != 4 {
+				return nil, fmt.Errorf("bad result line %q", line)
+			}
+			r.Outcome = fields[1]
+			r.Score, err = strconv.Atoi(fields[2])
+			if err == nil {
+				r.Ticks, err = strconv.Atoi(fields[3])
+			}
+			if err != nil {
+				return nil, fmt.Errorf("bad result line %q", line)
+			}
+		}
+	}
+	if r.Date == "" || r.Outcome == "" {
+		return nil, fmt.Errorf("not a daily replay")
+	}
+	r.Steps, err = ParseScript(strings.NewReader(string(b)))
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Verify plays the replay again on the day's board and checks it ends the
+// way the replay says it did.
+func (r *Replay) Verify() error {
+	day, err := time.Parse(time.DateOnly, r.Date)
+	if err != nil {
+		return fmt.Errorf("bad date %q", r.Date)
+	}
+	d := NewDailyChallenge(day)
+	g := NewGame(io.Discard)
+	g.Renderer = QuietRenderer{}
+	g.Config.Debug = r.Debug
+	d.Apply(g)
+	start := time.Unix(0, 0).UTC()
+	clock := NewManualClock(start)
+	g.Clock = clock
+	g.Init(d.Holes, d.Moles)
+	g.State = Playing
+	for _, s := range r.Steps {
+		if g.State == End {
+			return fmt.Errorf("game was over before the step at %s", s.At)
+		}
+		clock.Set(start.Add(s.At))
+		if s.Line == "tick" {
+			g.ProcessTick()
+		} else {
+			g.ProcessPlayerInput(s.Line)
+		}
+	}
+	if g.State != End {
+		g.end(Quit)
+	}
+	got := Replay{}
+	got.Finish(g)
+	if got.Outcome != r.Outcome || got.Score != r.Score || got.Ticks != r.Ticks {
+		return fmt.Errorf("replay ends %s with %d points after %d ticks, not %s with %d points after %d ticks", got.Outcome, got.Score, got.Ticks, r.Outcome, r.Score, r.Ticks)
+	}
+	return nil
+}
+
+// LeaderboardEntry is a player's ranked attempt at one day's challenge.
+type LeaderboardEntry struct {
+	Date    string        `json:"date"`
+	Player  string        `json:"player"`
+	Outcome string        `json:"outcome"`
+	Score   int           `json:"score"`
+	Elapsed time.Duration `json:"elapsed"`
+	Replay  string        `json:"replay"`
+}
+
+// Leaderboard is kept as a JSON file next to the achievements.
+type Leaderboard struct {
+	Entries []LeaderboardEntry `json:"entries"`
+}
+
+func DefaultLeaderboardPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "wam", "leaderboard.json")
+}
+
+func DefaultReplayDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "replays"
+	}
+	return filepath.Join(dir, "wam", "replays")
+}
+
+func LoadLeaderboard(path string) (*Leaderboard, error) {
+	lb := &Leaderboard{}
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return lb, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, lb); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return lb, nil
+}
+
+func (lb *Leaderboard) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(lb, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(b, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (lb *Leaderboard) Played(date, player string) bool {
+	for _, e := range lb.Entries {
+		if e.Date == date && e.Player == player {
+			return true
+		}
+	}
+	return false
+}
+
+// Record puts e on the board, in place of the player's undecided entry for
+// the day if the attempt was started there.
+func (lb *Leaderboard) Record(e LeaderboardEntry) {
+	for i, old := range lb.Entries {
+		if old.Date == e.Date && old.Player == e.Player && old.Outcome == Undecided.String() {
+			lb.Entries[i] = e
+			return
+		}
+	}
+	lb.Entries = append(lb.Entries, e)
+}
+
+// Day ranks the day's attempts: wins first, then by score, then the
+// quickest.
+func (lb *Leaderboard) Day(date string) []LeaderboardEntry {
+	var day []LeaderboardEntry
+	for _, e := range lb.Entries {
+		if e.Date == date {
+			day = append(day, e)
+		}
+	}
+	sort.SliceStable(day, func(i, j int) bool {
+		a, b := day[i], day[j]
+		if (a.Outcome == "won") != (b.Outcome == "won") {
+			return a.Outcome == "won"
+		}
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.Elapsed < b.Elapsed
+	})
+	return day
+}
+
+func leaderboardItems(day []LeaderboardEntry) []Fields {
+	var items []Fields
+	for i, e := range day {
+		items = append(items, Fields{"rank": i + 1, "player": e.Player, "outcome": word(e.Outcome), "score": e.Score, "elapsed": e.Elapsed.Round(time.Second)})
+	}
+	return items
+}
+
+// runLeaderboard shows a day's ranking, or checks a replay with -verify.
+func runLeaderboard(args []string, stdout io.Writer, stderr io.Writer) int {
+	fs := flag.NewFlagSet("wam leaderboard", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	date := fs.String("date", time.Now().UTC().Format(time.DateOnly), "day to show")
+	path := fs.String("file", DefaultLeaderboardPath(), "leaderboard file")
+	verify := fs.String("verify", "", "play this replay file again and check its result")
+	if err := fs.Parse(args); err != nil {
+		return ExitError
+	}
+	if *verify != "" {
+		f, err := os.Open(*verify)
+		if err != nil {
+			fmt.Fprintf(stderr, "%v\n", err)
+			return ExitError
+		}
+		r, err := ReadReplay(f)
+		f.Close()
+		if err == nil {
+			err = r.Verify()
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", *verify, err)
+			return ExitError
+		}
+		fmt.Fprintf(stdout, "%s: %s's %s challenge checks out, %s with %d points\n", *verify, r.Player, r.Date, r.Outcome, r.Score)
+		return 0
+	}
+	lb, err := LoadLeaderboard(*path)
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return ExitError
+	}
+	t := &TextRenderer{Out: stdout}
+	t.Render(Record{Type: ResponseRecord, Key: "daily.leaderboard", Fields: Fields{"date": *date}, Items: leaderboardItems(lb.Day(*date))})
+	return 0
+}
+
+// dailyFlags are the flags that can be given with -daily.  Everything else
+// would change the board.
+var dailyFlags = map[string]bool{"daily": true, "debug": true, "player": true, "leaderboard": true, "replays": true, "achievements": true, "achievement-defs": true, "output": true, "lang": true, "color": true, "theme": true, "a11y": true}
+
+type dailyOptions struct {
+	player       string
+	leaderboard  string
+	replays      string
+	achievements string
+	defs         []Achievement
+}
+
+// runDaily plays the day's challenge.  Only a player's first attempt each
+// day is ranked, and only without the debug commands; it goes on the
+// leaderboard along with a replay of the game.  Practice attempts with the
+// debug commands on still get a replay, but earn no achievements.
+func runDaily(g *Game, d DailyChallenge, o dailyOptions, stdin io.Reader, stderr io.Writer) int {
+	lb, err := LoadLeaderboard(o.leaderboard)
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return ExitError
+	}
+	var store *AchievementStore
+	if o.achievements != "" && !g.Config.Debug {
+		store, err = LoadAchievementStore(o.achievements)
+		if err != nil {
+			fmt.Fprintf(stderr, "%v\n", err)
+			return ExitError
+		}
+		g.Achievements = NewAchievements(o.defs, store.Player(o.player))
+	}
+	d.Apply(g)
+	ranked := !lb.Played(d.Date, o.player) && !g.Config.Debug
+	g.Replay = &Replay{Date: d.Date, Player: o.player, Debug: g.Config.Debug}
+	g.Init(d.Holes, d.Moles)
+	g.emit("daily.start", Fields{"date": d.Date, "mode": word(d.Mode), "holes": d.Holes, "moles": d.Moles, "armored": d.Armored, "entropy": d.Entropy})
+	switch {
+	case g.Config.Debug:
+		g.emit("daily.debug", nil)
+	case !ranked:
+		g.emit("daily.practice", Fields{"player": o.player})
+	}
+	if ranked {
+		// The attempt goes on the board before play starts, so quitting the
+		// process part way through still uses up the day's ranked attempt.
+		lb.Record(LeaderboardEntry{Date: d.Date, Player: o.player, Outcome: Undecided.String()})
+		if err := lb.Save(o.leaderboard); err != nil {
+			fmt.Fprintf(stderr, "saving leaderboard: %v\n", err)
+			return ExitError
+		}
+	}
+	commands := make(chan string)
+	scanner := g.InitForPlayer(stdin)
+	go g.ReadCommands(scanner, commands)
+	g.RunPlayLoop(commands)
+	if store != nil {
+		if err := store.Save(o.achievements); err != nil {
+			fmt.Fprintf(stderr, "saving achievements: %v\n", err)
+		}
+	}
+	if !ranked && !g.Config.Debug {
+		return g.ExitCode()
+	}
+
+	g.Replay.Finish(g)
+	name := d.Date + "-" + o.player
+	if !ranked {
+		name += "-debug"
+	}
+	path := filepath.Join(o.replays, name+".replay")
+	if err := writeReplay(path, g.Replay); err != nil {
+		fmt.Fprintf(stderr, "saving replay: %v\n", err)
+		path = ""
+	}
+	if !ranked {
+		if path != "" {
+			g.respond("daily.replay", Fields{"path": path})
+		}
+		return g.ExitCode()
+	}
+	lb.Record(LeaderboardEntry{Date: d.Date, Player: o.player, Outcome: g.Replay.Outcome, Score: g.Replay.Score, Elapsed: g.Elapsed(), Replay: path})
+	if err := lb.Save(o.leaderboard); err != nil {
+		fmt.Fprintf(stderr, "saving leaderboard: %v\n", err)
+		return g.ExitCode()
+	}
+	g.respondList("daily.leaderboard", Fields{"date": d.Date}, leaderboardItems(lb.Day(d.Date)))
+	return g.ExitCode()
+}
+
+func writeReplay(path string, r *Replay) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := r.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDailyChallenge(t *testing.T) {
+	day := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+	d := NewDailyChallenge(day)
+	assert.Equal(t, d, NewDailyChallenge(day.Add(10*time.Hour)))
+	assert.Equal(t, "2026-10-19", d.Date)
+	assert.NotEqual(t, d.Seed, NewDailyChallenge(day.AddDate(0, 0, 1)).Seed)
+	assert.NotEqual(t, d.Mode, NewDailyChallenge(day.AddDate(0, 0, 1)).Mode)
+	assert.True(t, d.Holes >= 6 && d.Holes <= 12)
+	assert.True(t, d.Moles >= d.Holes/2 && d.Moles <= d.Holes)
+
+	for _, mode := range []string{"classic", "survival"} {
+		d.Mode = mode
+		g := NewGame(&bytes.Buffer{})
+		d.Apply(g)
+		assert.Equal(t, mode, g.Config.Mode)
+		assert.Equal(t, map[string]time.Duration{"classic": 2 * time.Minute}[mode], g.Config.TimeLimit, mode)
+	}
+}
+
+func playDaily(t *testing.T, d DailyChallenge) *Replay {
+	g := NewGame(&bytes.Buffer{})
+	d.Apply(g)
+	start := time.Unix(0, 0).UTC()
+	clock := NewManualClock(start)
+	g.Clock = clock
+	g.Replay = &Replay{Date: d.Date, Player: "ann"}
+	g.Init(d.Holes, d.Moles)
+	g.Start()
+	for i := 1; i <= 20 && g.State != End; i++ {
+		clock.Set(start.Add(time.Duration(i) * time.Second))
+		g.ProcessTick()
+		clock.Advance(123456789 * time.Nanosecond)
+		for _, m := range g.MoleFactory.MoleSet.Index.Moles {
+			if m.State == ExposedAlive {
+				g.ProcessPlayerInput("whack " + strconv.Itoa(m.HoleOccupied.ID))
+				break
+			}
+		}
+	}
+	g.ProcessPlayerInput("quit")
+	g.Replay.Finish(g)
+	return g.Replay
+}
+
+func TestReplayVerifies(t *testing.T) {
+	d := NewDailyChallenge(time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC))
+	r := playDaily(t, d)
+	require.Greater(t, r.Score, 0)
+	var buf bytes.Buffer
+	require.NoError(t, r.Write(&buf))
+	assert.True(t, strings.HasPrefix(buf.String(), "# wam daily replay\n# date 2026-10-19\n# player ann\n"))
+	assert.Contains(t, buf.String(), "\n@1s tick\n@1.123456789s whack ")
+
+	read, err := ReadReplay(strings.NewReader(buf.String()))
+	require.NoError(t, err)
+	assert.Equal(t, r, read)
+	assert.NoError(t, read.Verify())
+
+	read.Score += 100
+	assert.ErrorContains(t, read.Verify(), "with "+strconv.Itoa(r.Score)+" points")
+	_, err = ReadReplay(strings.NewReader("@1s tick\n"))
+	assert.EqualError(t, err, "not a daily replay")
+}
+
+func TestDailyRun(t *testing.T) {
+	dir := t.TempDir()
+	args := []string{"-daily", "-player", "ann", "-leaderboard", filepath.Join(dir, "leaderboard.json"), "-replays", dir, "-achievements", ""}
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader("quit\n"), &stdout, &stderr)
+	assert.Equal(t, ExitQuit, code, stderr.String())
+	assert.Contains(t, stdout.String(), "Daily challenge for ")
+	assert.Contains(t, stdout.String(), "  1. ann: quit, 0 points in ")
+
+	lb, err := LoadLeaderboard(filepath.Join(dir, "leaderboard.json"))
+	require.NoError(t, err)
+	require.Len(t, lb.Entries, 1)
+	_, err = os.Stat(lb.Entries[0].Replay)
+	require.NoError(t, err)
+
+	stdout.Reset()
+	code = run(args, strings.NewReader("quit\n"), &stdout, &stderr)
+	assert.Equal(t, ExitQuit, code)
+	assert.Contains(t, stdout.String(), "ann has already played today, this attempt won't be ranked.")
+	lb, err = LoadLeaderboard(filepath.Join(dir, "leaderboard.json"))
+	require.NoError(t, err)
+	assert.Len(t, lb.Entries, 1)
+
+	stdout.Reset()
+	code = run([]string{"leaderboard", "-verify", lb.Entries[0].Replay}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, 0, code, stderr.String())
+	assert.Contains(t, stdout.String(), "checks out, quit with 0 points")
+
+	// A game cut short before it ends is still on the board.
+	mid := &peekingReader{path: filepath.Join(dir, "leaderboard.json")}
+	code = run([]string{"-daily", "-player", "bob", "-leaderboard", mid.path, "-replays", dir, "-achievements", ""}, mid, &stdout, &stderr)
+	assert.Equal(t, ExitQuit, code, stderr.String())
+	require.NotNil(t, mid.seen)
+	require.Len(t, mid.seen.Entries, 2)
+	assert.Equal(t, LeaderboardEntry{Date: lb.Entries[0].Date, Player: "bob", Outcome: "undecided"}, mid.seen.Entries[1])
+	lb, err = LoadLeaderboard(mid.path)
+	require.NoError(t, err)
+	require.Len(t, lb.Entries, 2)
+	assert.Equal(t, "quit", lb.Entries[1].Outcome)
+
+	stderr.Reset()
+	code = run([]string{"-daily", "-holes", "3", "-seed", "4"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, ExitError, code)
+	assert.Equal(t, "the daily challenge has fixed settings, drop -holes -seed\n", stderr.String())
+}
+
+// peekingReader loads the leaderboard when the game first reads a command,
+// then quits.
+type peekingReader struct {
+	path string
+	seen *Leaderboard
+	done bool
+}
+
+func (r *peekingReader) Read(p []byte) (int, error) {
+	if r.done {
+		return 0, io.EOF
+	}
+	r.done = true
+	r.seen, _ = LoadLeaderboard(r.path)
+	return copy(p, "quit\n"), nil
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// The debug commands, turned on with -debug, let a developer set up the
+// board by hand instead of waiting for the dice.  They go through the same
+// input as every other command, so they end up in transcripts and replays,
+// and they are never on for a ranked game.
+var debugCommands = map[string]bool{"spawn": true, "expose": true, "hide": true, "move": true, "freeze": true, "step": true, "dump": true, "seed": true}
+
+func (g *Game) SetSeed(seed int64) {
+	g.Seed = seed
+	g.Rand = rand.New(rand.NewSource(seed))
+}
+
+func (g *Game) ProcessDebugInput(parts []string) {
+	switch parts[0] {
+	case "spawn":
+		g.handleSpawn(argAt(parts, 1))
+	case "expose":
+		g.handleDebugToggle(argAt(parts, 1), ExposedAlive)
+	case "hide":
+		g.handleDebugToggle(argAt(parts, 1), HidingAlive)
+	case "move":
+		g.handleDebugMove(argAt(parts, 1), argAt(parts, 2))
+	case "freeze":
+		g.Frozen = !g.Frozen
+		g.respond("debug.freeze", Fields{"frozen": g.Frozen})
+	case "step":
+		g.handleStep()
+	case "dump":
+		g.handleDump()
+	case "seed":
+		g.handleSeed(argAt(parts, 1))
+	}
+}
+
+func (g *Game) debugMole(arg string) *Mole {
+	id, err := strconv.Atoi(arg)
+	if err == nil {
+		for _, m := range g.MoleFactory.MoleSet.Index.Moles {
+			if m.ID == id && m.State != Dead {
+				return m
+			}
+		}
+	}
+	g.respond("debug.no_mole", Fields{"mole": arg})
+	return nil
+}
+
+// handleSpawn adds n moles, housing each one if there is room.
+func (g *Game) handleSpawn(arg string) {
+	n := 1
+	if arg != "" {
+		var err error
+		if n, err = strconv.Atoi(arg); err != nil || n < 1 {
+			g.respond("debug.bad_count", Fields{"count": arg})
+			return
+		}
+	}
+	var ids []string
+	for range n {
+		m, err := g.MoleFactory.NewMole()
+		if err != nil {
+			break
+		}
+		m.TryOccupy(&g.HoleFactory.HoleSet)
+		ids = append(ids, strconv.Itoa(m.ID))
+	}
+	g.updateWinCondition()
+	g.winCheck()
+	g.respond("debug.spawned", Fields{"count": len(ids), "moles": strings.Join(ids, ", ")})
+}
+
+func (g *Game) handleDebugToggle(arg string, want MoleState) {
+	m := g.debugMole(arg)
+	if m == nil {
+		return
+	}
+	if m.HoleOccupied == nil {
+		g.respond("debug.not_housed", Fields{"mole": m.ID})
+		return
+	}
+	if m.State != want {
+		m.ToggleState()
+	}
+	g.respond("debug.mole", Fields{"mole": m.ID, "status": moleStatus(m)})
+}
+
+// handleDebugMove puts a mole straight into a hole, tunnels or not.
+func (g *Game) handleDebugMove(arg string, hole string) {
+	m := g.debugMole(arg)
+	if m == nil {
+		return
+	}
+	id, err := strconv.Atoi(hole)
+	target := g.HoleFactory.HoleSet.GetHole(id)
+	if err != nil || target == nil || target.State != Unoccupied {
+		g.respond("debug.bad_hole", Fields{"hole": hole})
+		return
+	}
+	if m.HoleOccupied != nil {
+		m.HoleOccupied.Free()
+	}
+	m.TravelTicks = 0
+	if !target.TryOccupy(m) {
+		g.winCheck()
+		return
+	}
+	m.Node = id
+	g.respond("debug.mole", Fields{"mole": m.ID, "status": moleStatus(m)})
+}
+
+// handleStep runs a single tick, frozen or not.  The tick is not written to
+// the replay on its own since the step command already is.
+func (g *Game) handleStep() {
+	frozen := g.Frozen
+	g.Frozen, g.stepping = false, true
+	g.ProcessTick()
+	g.Frozen, g.stepping = frozen, false
+	g.respond("debug.stepped", Fields{"tick": g.Ticks})
+}
+
+// handleDump lists every hole and mole along with the sets that hold them,
+// so a hole that is both available and unavailable stands out.
+func (g *Game) handleDump() {
+	hs := &g.HoleFactory.HoleSet
+	var holes []Fields
+	for _, h := range hs.Index.Holes {
+		var in []string
+		for _, set := range []struct {
+			name string
+			m    map[int]*Hole
+		}{{"available", hs.Available}, {"unavailable", hs.Unavailable}, {"collapsed", hs.Collapsed}, {"blocked", hs.Blocked}} {
+			if _, ok := set.m[h.ID]; ok {
+				in = append(in, set.name)
+			}
+		}
+		if hs.Index.IsOpen(h.ID) {
+			in = append(in, "open")
+		}
+		mole := "-"
+		if h.OccupyingMole != nil {
+			mole = strconv.Itoa(h.OccupyingMole.ID)
+		}
+		holes = append(holes, Fields{"hole": h.ID, "state": word(holeStatus(h)), "mole": mole, "sets": strings.Join(in, ",")})
+	}
+	g.respondList("debug.holes", Fields{"count": len(hs.Index.Holes)}, holes)
+
+	ms := &g.MoleFactory.MoleSet
+	var moles []Fields
+	for _, m := range ms.Index.Moles {
+		var in []string
+		for _, set := range []struct {
+			name string
+			m    map[int]*Mole
+		}{{"housed", ms.Housed}, {"unhoused", ms.Unhoused}, {"dead", ms.Dead}} {
+			if _, ok := set.m[m.ID]; ok {
+				in = append(in, set.name)
+			}
+		}
+		var flags []string
+		if m.Armored {
+			flags = append(flags, "armored")
+		}
+		if m.Controlled {
+			flags = append(flags, "controlled")
+		}
+		if m.TravelTicks > 0 {
+			flags = append(flags, "travelling to "+strconv.Itoa(m.Dest))
+		}
+		f := Fields{"mole": m.ID, "status": moleStatus(m), "sets": strings.Join(in, ","), "flags": ""}
+		if len(flags) > 0 {
+			f["flags"] = ", " + strings.Join(flags, ", ")
+		}
+		moles = append(moles, f)
+	}
+	g.respondList("debug.moles", Fields{"count": len(ms.Index.Moles), "win": g.WinCondition, "tick": g.Ticks, "frozen": g.Frozen}, moles)
+}
+
+// handleSeed shows the seed, or starts the dice again from a new one.  The
+// generator is reseeded in place since the hole selector shares it.
+func (g *Game) handleSeed(arg string) {
+	if arg != "" {
+		seed, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			g.respond("debug.bad_seed", Fields{"seed": arg})
+			return
+		}
+		g.Seed = seed
+		g.Rand.Seed(seed)
+	}
+	g.respond("debug.seed", Fields{"seed": g.Seed})
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugOff(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.Init(3, 1)
+	g.ProcessPlayerInput("spawn 2")
+	assert.Contains(t, buf.String(), "unknown commands")
+	assert.Len(t, g.MoleFactory.MoleSet.Index.Moles, 1)
+}
+
+func TestDebugSpawnMoveExpose(t *testing.T) {
+	g, _, buf := newTestGame(4, 1, debugRules)
+	g.ProcessPlayerInput("spawn 2")
+	assert.Contains(t, buf.String(), "[debug] spawned 2 moles: 2, 3\n")
+	assert.Equal(t, 3, g.WinCondition)
+	assert.Len(t, g.MoleFactory.MoleSet.Housed, 3)
+
+	g.ProcessPlayerInput("move 1 4")
+	assert.Contains(t, buf.String(), "[debug] mole 1 is hiding in hole 4\n")
+	g.ProcessPlayerInput("expose 1")
+	assert.Contains(t, buf.String(), "[debug] mole 1 is exposed in hole 4\n")
+	g.ProcessPlayerInput("whack 4")
+	assert.Contains(t, buf.String(), "bonked out of existence!")
+	g.ProcessPlayerInput("hide 1")
+	assert.Contains(t, buf.String(), "[debug] no living mole 1\n")
+	g.ProcessPlayerInput("move 2 2")
+	assert.Contains(t, buf.String(), "[debug] hole 2 isn't an empty hole\n")
+}
+
+func TestDebugFreezeAndStep(t *testing.T) {
+	g, _, buf := newTestGame(4, 2, debugRules)
+	g.ProcessPlayerInput("freeze")
+	assert.True(t, g.Frozen)
+	before := buf.Len()
+	g.ProcessTick()
+	g.ProcessTick()
+	assert.Zero(t, g.Ticks)
+	assert.Equal(t, before, buf.Len())
+	g.ProcessPlayerInput("step")
+	assert.Equal(t, 1, g.Ticks)
+	assert.True(t, g.Frozen)
+	assert.Contains(t, buf.String(), "[debug] stepped to tick 1\n")
+}
+
+func TestDebugDump(t *testing.T) {
+	g, _, buf := newTestGame(3, 1, debugRules)
+	g.ProcessPlayerInput("move 1 3")
+	g.ProcessPlayerInput("dump")
+	out := buf.String()
+	out = out[strings.Index(out, "[debug] 3 holes:"):]
+	assert.Equal(t, "[debug] 3 holes:\n"+
+		"  hole 1: empty, mole -, in available,open\n"+
+		"  hole 2: empty, mole -, in available,open\n"+
+		"  hole 3: hiding, mole 1, in unavailable\n"+
+		"[debug] 1 moles, 1 to win, tick 0, frozen: false\n"+
+		"  mole 1: hiding in hole 3, in housed\n> ", out)
+}
+
+func TestDebugSeed(t *testing.T) {
+	g, _, buf := newTestGame(3, 1, debugRules)
+	g.ProcessPlayerInput("seed")
+	assert.Contains(t, buf.String(), "[debug] seed 1\n")
+	g.ProcessPlayerInput("seed 42")
+	assert.Equal(t, int64(42), g.Seed)
+	a := g.Rand.Int63()
+	g.ProcessPlayerInput("seed 42")
+	assert.Equal(t, a, g.Rand.Int63())
+}
+
+func TestDebugReplay(t *testing.T) {
+	d := NewDailyChallenge(time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC))
+	g := NewGame(&bytes.Buffer{})
+	g.Config.Debug = true
+	d.Apply(g)
+	require.True(t, g.Config.Debug)
+	clock := NewManualClock(time.Unix(0, 0).UTC())
+	g.Clock = clock
+	g.Replay = &Replay{Date: d.Date, Player: "ann", Debug: true}
+	g.Init(d.Holes, d.Moles)
+	g.ProcessPlayerInput("freeze")
+	clock.Advance(time.Second)
+	g.ProcessTick()
+	g.ProcessPlayerInput("expose 1")
+	g.ProcessPlayerInput("whack " + strconv.Itoa(g.MoleFactory.MoleSet.Housed[1].HoleOccupied.ID))
+	g.ProcessPlayerInput("step")
+	g.ProcessPlayerInput("quit")
+	g.Replay.Finish(g)
+	assert.Equal(t, 100, g.Replay.Score)
+	assert.Equal(t, []ScriptCommand{{0, "freeze"}, {time.Second, "tick"}, {time.Second, "expose 1"}, {time.Second, g.Replay.Steps[3].Line}, {time.Second, "step"}, {time.Second, "quit"}}, g.Replay.Steps)
+
+	var buf bytes.Buffer
+	require.NoError(t, g.Replay.Write(&buf))
+	assert.Contains(t, buf.String(), "# debug on\n")
+	r, err := ReadReplay(&buf)
+	require.NoError(t, err)
+	assert.NoError(t, r.Verify())
+}
+
+func TestDebugDailyIsNotRanked(t *testing.T) {
+	dir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	achievements := filepath.Join(dir, "achievements.json")
+	code := run([]string{"-daily", "-debug", "-player", "ann", "-leaderboard", filepath.Join(dir, "leaderboard.json"), "-replays", dir, "-achievements", achievements}, strings.NewReader("seed\nquit\n"), &stdout, &stderr)
+	assert.Equal(t, ExitQuit, code, stderr.String())
+	assert.Contains(t, stdout.String(), "Debug commands are on, this attempt won't be ranked.")
+	lb, err := LoadLeaderboard(filepath.Join(dir, "leaderboard.json"))
+	require.NoError(t, err)
+	assert.Empty(t, lb.Entries)
+	b, err := os.ReadFile(filepath.Join(dir, NewDailyChallenge(time.Now()).Date+"-ann-debug.replay"))
+	require.NoError(t, err)
+	assert.Contains(t, string(b), " seed\n")
+	assert.NoFileExists(t, achievements)
+}
+
+func TestDebugEarnsNoAchievements(t *testing.T) {
+	achievements := filepath.Join(t.TempDir(), "achievements.json")
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-debug", "-holes", "1", "-moles", "1", "-achievements", achievements}, strings.NewReader("quit\n"), &stdout, &stderr)
+	assert.Equal(t, ExitQuit, code, stderr.String())
+	assert.NoFileExists(t, achievements)
+
+	code = run([]string{"-holes", "1", "-moles", "1", "-achievements", achievements}, strings.NewReader("quit\n"), &stdout, &stderr)
+	assert.Equal(t, ExitQuit, code, stderr.String())
+	assert.FileExists(t, achievements, "the same game without -debug keeps them")
+}
+
+func debugRules(g *Game) {
+	g.Config.Debug = true
+	g.Config.Entropy = 100
+	g.SetSeed(1)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"time"
+)
+
+// AdaptiveRules tune the difficulty controller.  After every Window swings
+// it compares the player's hit rate over those swings with TargetHitRate:
+// above the band it makes the game harder, below it easier, and inside the
+// band a mean reaction faster than FastReaction still counts as too easy.
+// Harder means more entropy, shorter exposures and shorter ticks, each kept
+// within its bounds.  A zero TargetHitRate turns the controller off.
+type AdaptiveRules struct {
+	TargetHitRate  float64
+	Tolerance      float64
+	Window         int
+	FastReaction   time.Duration
+	EntropyStep    int
+	MinEntropy     int
+	MaxEntropy     int
+	MinExposeTicks int
+	MaxExposeTicks int
+	MinTick        time.Duration
+	MaxTick        time.Duration
+}
+
+func DefaultAdaptiveRules() AdaptiveRules {
+	return AdaptiveRules{
+		TargetHitRate:  0.6,
+		Tolerance:      0.1,
+		Window:         10,
+		FastReaction:   600 * time.Millisecond,
+		EntropyStep:    5,
+		MinEntropy:     10,
+		MaxEntropy:     90,
+		MinExposeTicks: 1,
+		MaxExposeTicks: 8,
+		MinTick:        400 * time.Millisecond,
+		MaxTick:        2 * time.Second,
+	}
+}
+
+// DifficultyChange is one decision of the controller, kept for review.
+type DifficultyChange struct {
+	Tick        int
+	At          time.Duration
+	HitRate     float64
+	Reaction    time.Duration
+	Direction   string
+	Entropy     int
+	ExposeTicks int
+	TickRate    time.Duration
+}
+
+type Difficulty struct {
+	Rules     AdaptiveRules
+	Log       []DifficultyChange
+	swings    int
+	hits      int
+	reactions int
+}
+
+// adapt counts a swing and, once the window is full, adjusts the game.
+func (g *Game) adapt(hit bool) {
+	d := g.Difficulty
+	if d == nil {
+		return
+	}
+	d.swings++
+	if hit {
+		d.hits++
+	}
+	if d.swings < d.Rules.Window {
+		return
+	}
+	r := d.Rules
+	rate := float64(d.hits) / float64(d.swings)
+	var reaction time.Duration
+	if rs := g.MoleFactory.MoleSet.History.Reactions[d.reactions:]; len(rs) > 0 {
+		for _, rt := range rs {
+			reaction += rt
+		}
+		reaction /= time.Duration(len(rs))
+	}
+	d.swings, d.hits = 0, 0
+	d.reactions = len(g.MoleFactory.MoleSet.History.Reactions)
+
+	dir := "hold"
+	switch {
+	case rate > r.TargetHitRate+r.Tolerance:
+		dir = "harder"
+	case rate < r.TargetHitRate-r.Tolerance:
+		dir = "easier"
+	case reaction > 0 && reaction < r.FastReaction:
+		dir = "harder"
+	}
+	c := &g.Config
+	before := *c
+	switch dir {
+	case "harder":
+		c.Entropy = min(c.Entropy+r.EntropyStep, r.MaxEntropy)
+		c.ExposeTicks = max(c.ExposeTicks-1, r.MinExposeTicks)
+		c.Tick = max(c.Tick*9/10, r.MinTick).Round(time.Millisecond)
+	case "easier":
+		c.Entropy = max(c.Entropy-r.EntropyStep, r.MinEntropy)
+		c.ExposeTicks = min(c.ExposeTicks+1, r.MaxExposeTicks)
+		c.Tick = min(c.Tick*10/9, r.MaxTick).Round(time.Millisecond)
+	}
+	change := DifficultyChange{
+		Tick:        g.Ticks,
+		At:          g.Elapsed(),
+		HitRate:     rate,
+		Reaction:    reaction,
+		Direction:   dir,
+		Entropy:     c.Entropy,
+		ExposeTicks: c.ExposeTicks,
+		TickRate:    c.Tick,
+	}
+	d.Log = append(d.Log, change)
+	// Every decision is logged for review, but holding steady, or pushing
+	// against the limits, changes nothing worth telling the player about.
+	if c.Entropy == before.Entropy && c.ExposeTicks == before.ExposeTicks && c.Tick == before.Tick {
+		return
+	}
+	g.emit("difficulty.changed", changeFields(change))
+}
+
+func changeFields(c DifficultyChange) Fields {
+	return Fields{
+		"direction":    word(c.Direction),
+		"hit_rate":     100 * c.HitRate,
+		"reaction":     c.Reaction.Round(time.Millisecond),
+		"entropy":      c.Entropy,
+		"expose_ticks": c.ExposeTicks,
+		"tick":         c.TickRate,
+	}
+}
+
+// hideOverexposed sends moles that have been out for Config.ExposeTicks
+// back into their holes.
+func (g *Game) hideOverexposed(m *Mole) {
+	if m.State != ExposedAlive {
+		m.ExposedTicks = 0
+		return
+	}
+	m.ExposedTicks++
+	if g.Config.ExposeTicks > 0 && m.ExposedTicks > g.Config.ExposeTicks {
+		m.ToggleState()
+		m.ExposedTicks = 0
+		g.emit("mole.vanished", Fields{"mole": m.ID})
+	}
+}
+
+func (g *Game) handleDifficulty() {
+	d := g.Difficulty
+	if d == nil {
+		g.respond("difficulty.fixed", Fields{"entropy": g.Config.Entropy, "tick": g.Config.Tick})
+		return
+	}
+	var items []Fields
+	for _, c := range d.Log {
+		f := changeFields(c)
+		f["at"] = c.At.Round(100 * time.Millisecond)
+		items = append(items, f)
+	}
+	g.respondList("difficulty", Fields{"entropy": g.Config.Entropy, "expose_ticks": g.Config.ExposeTicks, "tick": g.Config.Tick, "target": 100 * d.Rules.TargetHitRate}, items)
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExposeTicks(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.Config.Entropy = 0
+	g.Config.ExposeTicks = 2
+	g.Init(2, 1)
+	m := g.MoleFactory.MoleSet.Housed[1]
+	m.ToggleState()
+	g.ProcessTick()
+	g.ProcessTick()
+	assert.Equal(t, ExposedAlive, m.State)
+	g.ProcessTick()
+	assert.Equal(t, HidingAlive, m.State)
+	assert.Contains(t, buf.String(), "mole 1 vanished!")
+}
+
+func adaptiveRules(g *Game) {
+	g.Config.Entropy = 30
+	rules := DefaultAdaptiveRules()
+	rules.Window = 4
+	g.Config.Adaptive = rules
+}
+
+func TestAdaptiveHarder(t *testing.T) {
+	g, _, buf := newTestGame(3, 3, adaptiveRules)
+	require.NotNil(t, g.Difficulty)
+	assert.Equal(t, 8, g.Config.ExposeTicks)
+	for range 4 {
+		g.adapt(true)
+	}
+	assert.Equal(t, 35, g.Config.Entropy)
+	assert.Equal(t, 7, g.Config.ExposeTicks)
+	assert.Equal(t, 900*time.Millisecond, g.Config.Tick)
+	require.Len(t, g.Difficulty.Log, 1)
+	assert.Equal(t, "harder", g.Difficulty.Log[0].Direction)
+	assert.Contains(t, buf.String(), "Difficulty harder (hit rate 100%): entropy 35, moles stay out 7 ticks, tick 900ms")
+
+	for range 40 * 4 {
+		g.adapt(true)
+	}
+	assert.Equal(t, 90, g.Config.Entropy)
+	assert.Equal(t, 1, g.Config.ExposeTicks)
+	assert.Equal(t, 400*time.Millisecond, g.Config.Tick)
+
+	// At the limits the decision is still logged, but there is nothing left
+	// to change to tell the player about.
+	n := len(g.Difficulty.Log)
+	buf.Reset()
+	for range 4 {
+		g.adapt(true)
+	}
+	assert.Len(t, g.Difficulty.Log, n+1)
+	assert.Empty(t, buf.String())
+}
+
+func TestAdaptiveEasierAndHold(t *testing.T) {
+	g, _, buf := newTestGame(3, 3, adaptiveRules)
+	for range 4 {
+		g.adapt(false)
+	}
+	assert.Equal(t, 25, g.Config.Entropy)
+	assert.Equal(t, 8, g.Config.ExposeTicks)
+	assert.Equal(t, 1111*time.Millisecond, g.Config.Tick)
+
+	g.adapt(true)
+	g.adapt(false)
+	g.adapt(true)
+	g.adapt(false)
+	require.Len(t, g.Difficulty.Log, 2)
+	assert.Equal(t, "hold", g.Difficulty.Log[1].Direction)
+	assert.NotContains(t, buf.String(), "Difficulty steady", "holding steady isn't announced")
+	assert.Equal(t, 25, g.Config.Entropy)
+
+	// On target but reacting quickly still counts as too easy.
+	g.MoleFactory.MoleSet.History.Reactions = append(g.MoleFactory.MoleSet.History.Reactions, 200*time.Millisecond)
+	g.adapt(true)
+	g.adapt(false)
+	g.adapt(true)
+	g.adapt(false)
+	assert.Equal(t, "harder", g.Difficulty.Log[2].Direction)
+	assert.Equal(t, 200*time.Millisecond, g.Difficulty.Log[2].Reaction)
+
+	g.ProcessPlayerInput("difficulty")
+	assert.Contains(t, buf.String(), "  @0s steady: hit rate 50%, reaction 0s -> entropy 25, 8 ticks out, tick 1.111s\n")
+}
+
+func TestAdaptiveFollowsSwings(t *testing.T) {
+	g, _, _ := newTestGame(3, 3, adaptiveRules)
+	g.Config.Hammers = []Hammer{{Name: "mallet"}}
+	g.Armory = NewArmory(g.Config.Hammers)
+	for range 4 {
+		g.ProcessPlayerInput("whack 1")
+	}
+	require.Len(t, g.Difficulty.Log, 1)
+	assert.Equal(t, "easier", g.Difficulty.Log[0].Direction)
+}
+
+func TestDifficultyCommand(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.Init(3, 3)
+	g.ProcessPlayerInput("difficulty")
+	assert.Contains(t, buf.String(), "Entropy 30, tick 1s, adaptive difficulty is off.")
+
+	g, _, b := newTestGame(3, 3, adaptiveRules)
+	for range 4 {
+		g.adapt(false)
+	}
+	b.Reset()
+	g.ProcessPlayerInput("difficulty")
+	lines := strings.Split(b.String(), "\n")
+	assert.Equal(t, "Entropy 25, moles stay out 8 ticks, tick 1.111s, aiming for 60% hits", lines[0])
+	assert.Equal(t, "  @0s easier: hit rate 0%, reaction 0s -> entropy 25, 8 ticks out, tick 1.111s", lines[1])
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	WaitAction  = "wait"
+	WhackAction = "whack"
+)
+
+type Action struct {
+	Type string `json:"type"`
+	Hole int    `json:"hole,omitempty"`
+}
+
+// Rewards maps whack outcomes and the end of the game to a score for agents.
+type Rewards struct {
+	Hit   float64 `json:"hit"`
+	Miss  float64 `json:"miss"`
+	Whiff float64 `json:"whiff"`
+	Wait  float64 `json:"wait"`
+	Win   float64 `json:"win"`
+	Lose  float64 `json:"lose"`
+}
+
+func DefaultRewards() Rewards {
+	return Rewards{Hit: 1, Miss: -0.5, Whiff: -0.25, Wait: 0, Win: 5, Lose: -5}
+}
+
+// Env wraps a Game as a step based environment for automated players.  Each
+// step applies one action and then advances the moles by one tick.
+type Env struct {
+	Holes    int
+	Moles    int
+	Entropy  int
+	Tick     time.Duration
+	MaxSteps int
+	Reveal   bool
+	Rewards  Rewards
+
+	game  *Game
+	clock *ManualClock
+}
+
+func NewEnv(holes int, moles int) *Env {
+	return &Env{
+		Holes:    holes,
+		Moles:    moles,
+		Entropy:  DefaultConfig().Entropy,
+		Tick:     DefaultConfig().Tick,
+		MaxSteps: 300,
+		Rewards:  DefaultRewards(),
+	}
+}
+
+func (e *Env) Game() *Game {
+	return e.game
+}
+
+func (e *Env) Reset(seed int64) Observation {
+	e.clock = NewManualClock(time.Unix(0, 0))
+	e.game = NewGame(io.Discard)
+	e.game.Renderer = QuietRenderer{}
+	e.game.Clock = e.clock
+	e.game.SetSeed(seed)
+	e.game.Config.Entropy = e.Entropy
+	e.game.Config.Tick = e.Tick
+	e.game.Config.TimeLimit = time.Duration(e.MaxSteps) * e.Tick
+	e.game.Init(e.Holes, e.Moles)
+	e.game.State = Playing
+	return e.observe()
+}
+
+func (e *Env) observe() Observation {
+	obs := e.game.Observe()
+	if e.Reveal {
+		for i := range obs.Holes {
+			h := e.game.HoleFactory.HoleSet.GetHole(obs.Holes[i].ID)
+			obs.Holes[i].Occupied = h.OccupyingMole != nil && h.OccupyingMole.State != Dead
+		}
+	}
+	return obs
+}
+
+func (e *Env) Step(a Action) (Observation, float64, bool, error) {
+	if e.game == nil {
+		return Observation{}, 0, false, fmt.Errorf("reset the environment before stepping")
+	}
+	if e.game.State == End {
+		return e.observe(), 0, true, fmt.Errorf("episode is over, reset to play again")
+	}
+
+	reward := 0.0
+	switch a.Type {
+	case WaitAction:
+		reward += e.Rewards.Wait
+	case WhackAction:
+		if e.game.HoleFactory.HoleSet.GetHole(a.Hole) == nil {
+			return e.observe(), 0, false, fmt.Errorf("hole %d does not exist", a.Hole)
+		}
+		before := e.game.Stats
+		e.game.ProcessPlayerInput(fmt.Sprintf("whack %d", a.Hole))
+		after := e.game.Stats
+		reward += float64(after.Hits-before.Hits) * e.Rewards.Hit
+		reward += float64(after.Misses-before.Misses) * e.Rewards.Miss
+		reward += float64(after.Whiffs-before.Whiffs) * e.Rewards.Whiff
+	default:
+		return e.observe(), 0, false, fmt.Errorf("unknown action %q", a.Type)
+	}
+
+	if e.game.State != End {
+		e.clock.Advance(e.Tick)
+		e.game.ProcessTick()
+	}
+	done := e.game.State == End
+	if done {
+		if e.game.Outcome == Won {
+			reward += e.Rewards.Win
+		} else {
+			reward += e.Rewards.Lose
+		}
+	}
+	return e.observe(), reward, done, nil
+}
+
+type gymRequest struct {
+	Op     string `json:"op"`
+	Seed   int64  `json:"seed"`
+	Action Action `json:"action"`
+}
+
+type gymResponse struct {
+	Observation *Observation `json:"observation,omitempty"`
+	Reward      float64      `json:"reward"`
+	Done        bool         `json:"done"`
+	Error       string       `json:"error,omitempty"`
+}
+
+// ServeGym speaks a JSON-lines protocol so agents written in other languages
+// can drive an Env.  Each request line is one of
+//
+//	{"op":"reset","seed":1}
+//	{"op":"step","action":{"type":"whack","hole":2}}
+//	{"op":"step","action":{"type":"wait"}}
+//	{"op":"close"}
+//
+// and is answered with one response line.
+func ServeGym(env *Env, in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	enc := json.NewEncoder(out)
+	for scanner.Scan() {
+		var req gymRequest
+		var resp gymResponse
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			resp.Error = fmt.Sprintf("bad request: %v", err)
+			if err := enc.Encode(resp); err != nil {
+				return err
+			}
+			continue
+		}
+		switch req.Op {
+		case "reset":
+			obs := env.Reset(req.Seed)
+			resp.Observation = &obs
+		case "step":
+			obs, reward, done, err := env.Step(req.Action)
+			resp.Observation, resp.Reward, resp.Done = &obs, reward, done
+			if err != nil {
+				resp.Error = err.Error()
+			}
+		case "close":
+			return nil
+		default:
+			resp.Error = fmt.Sprintf("unknown op %q", req.Op)
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func runGym(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	fs := flag.NewFlagSet("wam gym", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	holes := fs.Int("holes", 3, "number of holes on the board")
+	moles := fs.Int("moles", 3, "number of moles to whack")
+	entropy := fs.Int("entropy", 30, "percent chance per tick that a mole moves or changes exposure")
+	maxSteps := fs.Int("max-steps", 300, "steps before an episode counts as lost")
+	reveal := fs.Bool("reveal", false, "show hiding moles in observations")
+	if err := fs.Parse(args); err != nil {
+		return ExitError
+	}
+	env := NewEnv(*holes, *moles)
+	env.Entropy = *entropy
+	env.MaxSteps = *maxSteps
+	env.Reveal = *reveal
+	if err := ServeGym(env, stdin, stdout); err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return ExitError
+	}
+	return 0
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvStep(t *testing.T) {
+	env := NewEnv(3, 3)
+	env.Entropy = 100
+	obs := env.Reset(1)
+	assert.Empty(t, obs.ExposedHoles())
+
+	_, _, _, err := env.Step(Action{Type: WhackAction, Hole: 9})
+	require.Error(t, err)
+
+	obs, reward, done, err := env.Step(Action{Type: WaitAction})
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, reward)
+	assert.False(t, done)
+	assert.Equal(t, []int{1, 2, 3}, obs.ExposedHoles())
+
+	obs, reward, done, err = env.Step(Action{Type: WhackAction, Hole: 1})
+	require.NoError(t, err)
+	assert.Equal(t, env.Rewards.Hit, reward)
+	assert.False(t, done)
+	assert.Equal(t, 2, obs.MolesLeft)
+
+	obs, _, _, _ = env.Step(Action{Type: WhackAction, Hole: obs.ExposedHoles()[0]})
+	_, reward, done, err = env.Step(Action{Type: WhackAction, Hole: obs.ExposedHoles()[0]})
+	require.NoError(t, err)
+	assert.True(t, done)
+	assert.Equal(t, env.Rewards.Hit+env.Rewards.Win, reward)
+
+	_, _, _, err = env.Step(Action{Type: WaitAction})
+	require.Error(t, err)
+}
+
+func TestEnvEpisodeLimit(t *testing.T) {
+	env := NewEnv(3, 3)
+	env.Entropy = 0
+	env.MaxSteps = 2
+	env.Reveal = true
+	obs := env.Reset(3)
+	assert.Equal(t, []HoleView{{ID: 1, Occupied: true}, {ID: 2, Occupied: true}, {ID: 3, Occupied: true}}, obs.Holes)
+
+	_, reward, done, _ := env.Step(Action{Type: WhackAction, Hole: 1})
+	assert.Equal(t, env.Rewards.Miss, reward)
+	assert.False(t, done)
+	_, reward, done, _ = env.Step(Action{Type: WaitAction})
+	assert.True(t, done)
+	assert.Equal(t, env.Rewards.Lose, reward)
+}
+
+func TestServeGym(t *testing.T) {
+	env := NewEnv(3, 3)
+	env.Entropy = 100
+	in := strings.NewReader(`{"op":"reset","seed":5}
+{"op":"step","action":{"type":"wait"}}
+{"op":"step","action":{"type":"whack","hole":2}}
+{"op":"dance"}
+not json
+{"op":"close"}
+{"op":"step","action":{"type":"wait"}}
+`)
+	var out bytes.Buffer
+	require.NoError(t, ServeGym(env, in, &out))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 5)
+	var resp gymResponse
+	require.NoError(t, json.Unmarshal([]byte(lines[2]), &resp))
+	assert.Equal(t, 1.0, resp.Reward)
+	assert.Equal(t, 2, resp.Observation.MolesLeft)
+	assert.Contains(t, lines[3], "unknown op")
+	assert.Contains(t, lines[4], "bad request")
+}
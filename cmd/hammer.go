@@ -0,0 +1,239 @@
+package main
+
+import (
+	"time"
+)
+
+// Hammer describes one of the player's hammers.  A spreading hammer also
+// swings at the holes next to the target, connecting with each of them
+// SplashAccuracy percent of the time.  Only piercing hammers get through
+// armor.
+type Hammer struct {
+	Name           string
+	Cooldown       time.Duration
+	Spread         bool
+	SplashAccuracy int
+	Piercing       bool
+}
+
+func DefaultHammers() []Hammer {
+	return []Hammer{
+		{Name: "mallet"},
+		{Name: "wide", Cooldown: 2 * time.Second, Spread: true, SplashAccuracy: 60},
+		{Name: "heavy", Cooldown: 4 * time.Second, Piercing: true},
+	}
+}
+
+type Armory struct {
+	Hammers    []Hammer
+	Current    int
+	ReadyAt    map[string]time.Duration
+	Stats      map[string]*Stats
+	LastTarget int
+}
+
+func NewArmory(hammers []Hammer) *Armory {
+	a := &Armory{
+		Hammers: hammers,
+		ReadyAt: make(map[string]time.Duration),
+		Stats:   make(map[string]*Stats),
+	}
+	for _, hm := range hammers {
+		a.Stats[hm.Name] = &Stats{}
+	}
+	return a
+}
+
+func (a *Armory) Hammer() Hammer {
+	return a.Hammers[a.Current]
+}
+
+func (a *Armory) Switch(name string) bool {
+	for i, hm := range a.Hammers {
+		if hm.Name == name {
+			a.Current = i
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Game) ArmorMoles(n int) {
+	if n <= 0 {
+		return
+	}
+	moles := sortedMoles(g.MoleFactory.MoleSet.Unhoused)
+	g.Rand.Shuffle(len(moles), func(i, j int) { moles[i], moles[j] = moles[j], moles[i] })
+	for _, m := range moles[:min(n, len(moles))] {
+		m.Armored = true
+	}
+}
+
+// Neighbours returns the holes next to id.  With a tunnel network these are
+// the holes it has tunnels to, otherwise the board is laid out as a square
+// grid, filled row by row in hole order.
+func (g *Game) Neighbours(id int) []int {
+	n := g.HoleFactory.HoleId
+	if id < 1 || id > n {
+		return nil
+	}
+	if g.Tunnels != nil {
+		return g.Tunnels.Neighbours(id)
+	}
+	w := gridWidth(n)
+	row, col := (id-1)/w, (id-1)%w
+	var ids []int
+	if row > 0 {
+		ids = append(ids, id-w)
+	}
+	if col > 0 {
+		ids = append(ids, id-1)
+	}
+	if col < w-1 && id+1 <= n {
+		ids = append(ids, id+1)
+	}
+	if id+w <= n {
+		ids = append(ids, id+w)
+	}
+	return ids
+}
+
+func (o WhackOutcome) Name() string {
+	switch o {
+	case Hit:
+		return "bonked"
+	case Miss:
+		return "missed"
+	case Deflected:
+		return "clang"
+	default:
+		return "whiff"
+	}
+}
+
+func (g *Game) recordWhack(hm Hammer, o WhackOutcome) {
+	g.Stats.Record(o)
+	g.Armory.Stats[hm.Name].Record(o)
+}
+
+// swung scores a finished swing that bonked hits moles and lets the
+// difficulty controller know how it went.
+func (g *Game) swung(hits int) {
+	g.scoreSwing(hits)
+	g.adapt(hits > 0)
+}
+
+// swing brings the current hammer down on target, and on its neighbours for
+// a spreading hammer, once the hammer has cooled down.
+func (g *Game) swing(target *Hole) {
+	hm := g.Armory.Hammer()
+	if wait := g.Armory.ReadyAt[hm.Name] - g.Elapsed(); wait > 0 {
+		g.respond("hammer.cooling", Fields{"hammer": hm.Name, "wait": wait.Round(100 * time.Millisecond)})
+		return
+	}
+	g.Armory.ReadyAt[hm.Name] = g.Elapsed() + hm.Cooldown
+	g.Armory.LastTarget = target.ID
+
+	if !hm.Spread {
+		m := target.OccupyingMole
+		outcome := target.WhackWith(hm.Piercing)
+		g.recordWhack(hm, outcome)
+		switch outcome {
+		case Hit:
+			g.respond("whack.hit", Fields{"hammer": hm.Name, "hole": target.ID, "mole": m.ID})
+			g.swung(1)
+			g.winCheck()
+			return
+		case Deflected:
+			g.respond("whack.deflected", Fields{"hammer": hm.Name, "hole": target.ID, "mole": m.ID})
+		case Miss:
+			g.respond("whack.miss", Fields{"hammer": hm.Name, "hole": target.ID})
+		default:
+			g.respond("whack.whiff", Fields{"hammer": hm.Name, "hole": target.ID})
+		}
+		g.swung(0)
+		return
+	}
+
+	targets := []*Hole{target}
+	for _, id := range g.Neighbours(target.ID) {
+		if h := g.HoleFactory.HoleSet.GetHole(id); h != nil {
+			targets = append(targets, h)
+		}
+	}
+	// Only the target counts as a whack in the stats.  The splash on the
+	// neighbours is a bonus: a mole it bonks counts as a hit, but a glance or
+	// an empty hole beside the target doesn't count against the player.
+	var items []Fields
+	hits := 0
+	for i, h := range targets {
+		if i > 0 && g.Rand.Intn(100) >= hm.SplashAccuracy {
+			items = append(items, Fields{"hole": h.ID, "result": word("glanced")})
+			continue
+		}
+		item := Fields{"hole": h.ID}
+		if h.OccupyingMole != nil {
+			item["mole"] = h.OccupyingMole.ID
+		}
+		outcome := h.WhackWith(hm.Piercing)
+		if i == 0 || outcome == Hit {
+			g.recordWhack(hm, outcome)
+		}
+		item["result"] = word(outcome.Name())
+		items = append(items, item)
+		if outcome == Hit {
+			hits++
+		}
+	}
+	g.respondList("whack.sweep", Fields{"hammer": hm.Name, "hole": target.ID, "hits": hits}, items)
+	g.swung(hits)
+	if hits > 0 {
+		g.winCheck()
+	}
+}
+
+func (g *Game) handleHammer(args []string) {
+	if len(args) == 0 {
+		var items []Fields
+		for _, hm := range g.Armory.Hammers {
+			status := word("ready")
+			if wait := g.Armory.ReadyAt[hm.Name] - g.Elapsed(); wait > 0 {
+				status = Text{Key: "word.cooling", Fields: Fields{"wait": wait.Round(100 * time.Millisecond)}}
+			}
+			items = append(items, Fields{"hammer": hm.Name, "cooldown": hm.Cooldown, "status": status})
+		}
+		g.respondList("hammer.list", Fields{"current": g.Armory.Hammer().Name}, items)
+		return
+	}
+	if !g.Armory.Switch(args[0]) {
+		g.respond("hammer.unknown", Fields{"hammer": args[0]})
+		return
+	}
+	g.respond("hammer.switched", Fields{"hammer": args[0]})
+}
+
+func statsFields(s *Stats) Fields {
+	return Fields{
+		"whacks":    s.Whacks,
+		"hits":      s.Hits,
+		"misses":    s.Misses,
+		"whiffs":    s.Whiffs,
+		"deflected": s.Deflected,
+		"accuracy":  100 * s.Accuracy(),
+	}
+}
+
+func (g *Game) handleStats() {
+	var items []Fields
+	for _, hm := range g.Armory.Hammers {
+		f := statsFields(g.Armory.Stats[hm.Name])
+		f["hammer"] = hm.Name
+		items = append(items, f)
+	}
+	f := statsFields(&g.Stats)
+	f["score"] = g.Combo.Score
+	f["longest_streak"] = g.Combo.LongestStreak
+	f["max_multiplier"] = max(g.Combo.MaxMultiplier, 1)
+	f["frenzies"] = g.Combo.Frenzies
+	g.respondList("stats", f, items)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNeighbours(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.Init(8, 0)
+	// 1 2 3
+	// 4 5 6
+	// 7 8
+	assert.Equal(t, []int{2, 4}, g.Neighbours(1))
+	assert.Equal(t, []int{2, 4, 6, 8}, g.Neighbours(5))
+	assert.Equal(t, []int{3, 5}, g.Neighbours(6))
+	assert.Equal(t, []int{5, 7}, g.Neighbours(8))
+	assert.Nil(t, g.Neighbours(9))
+}
+
+func TestArmoredMoles(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.Config.ArmoredMoles = 3
+	g.Init(3, 3)
+	m := g.MoleFactory.MoleSet.Housed[1]
+	h := m.HoleOccupied
+	assert.True(t, m.Armored)
+	assert.Equal(t, Miss, h.Whack())
+	m.ToggleState()
+	assert.Equal(t, Deflected, h.Whack())
+	assert.False(t, m.TryWhack())
+	assert.Equal(t, Hit, h.WhackWith(true))
+	assert.Equal(t, Dead, m.State)
+}
+
+func TestHammerCooldownAndStats(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	clock := NewManualClock(time.Unix(0, 0))
+	g.Clock = clock
+	g.Config.ArmoredMoles = 1
+	g.Init(3, 2)
+	armored, plain := g.MoleFactory.MoleSet.Housed[1], g.MoleFactory.MoleSet.Housed[2]
+	if !armored.Armored {
+		armored, plain = plain, armored
+	}
+	armored.ToggleState()
+	plain.ToggleState()
+
+	g.ProcessPlayerInput("whack " + strconv.Itoa(armored.HoleOccupied.ID))
+	assert.Contains(t, buf.String(), "CLANG!")
+	g.ProcessPlayerInput("hammer heavy")
+	g.ProcessPlayerInput("whack " + strconv.Itoa(armored.HoleOccupied.ID))
+	assert.Equal(t, Dead, armored.State)
+	g.ProcessPlayerInput("whack " + strconv.Itoa(plain.HoleOccupied.ID))
+	assert.Contains(t, buf.String(), "heavy is still cooling down, wait 4s")
+	assert.Equal(t, ExposedAlive, plain.State)
+
+	clock.Advance(4 * time.Second)
+	buf.Reset()
+	g.ProcessPlayerInput("hammer")
+	assert.Contains(t, buf.String(), "holding the heavy")
+	assert.Contains(t, buf.String(), "wide: cooldown 2s, ready")
+	g.ProcessPlayerInput("hammer spoon")
+	assert.Contains(t, buf.String(), "called spoon")
+
+	g.ProcessPlayerInput("whack " + strconv.Itoa(plain.HoleOccupied.ID))
+	assert.Equal(t, Won, g.Outcome)
+	assert.Equal(t, Stats{Whacks: 3, Hits: 2, Misses: 1, Deflected: 1}, g.Stats)
+	assert.Equal(t, Stats{Whacks: 2, Hits: 2}, *g.Armory.Stats["heavy"])
+	assert.Equal(t, Stats{Whacks: 1, Misses: 1, Deflected: 1}, *g.Armory.Stats["mallet"])
+
+	buf.Reset()
+	g.ProcessPlayerInput("stats")
+	assert.Contains(t, buf.String(), "Accuracy: 67%")
+	assert.Contains(t, buf.String(), "heavy: 2 whacks, 2 hits")
+}
+
+func TestWideHammer(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.Config.Hammers = []Hammer{{Name: "wide", Spread: true, SplashAccuracy: 100}}
+	g.Init(4, 3)
+	for _, m := range g.MoleFactory.MoleSet.Housed {
+		m.ToggleState()
+	}
+	// 1 2
+	// 3 4
+	g.ProcessPlayerInput("whack 1")
+	assert.Contains(t, buf.String(), "SWOOSH!\n  hole 1: bonked\n  hole 2: bonked\n  hole 3: bonked\n")
+	assert.Equal(t, Won, g.Outcome)
+
+	g = NewGame(&buf)
+	g.Config.Hammers = []Hammer{{Name: "wide", Spread: true, SplashAccuracy: 0}}
+	g.Init(4, 4)
+	g.ProcessPlayerInput("whack 4")
+	assert.Equal(t, Stats{Whacks: 1, Misses: 1}, g.Stats)
+
+	// Glancing off the neighbours doesn't spoil a clean hit on the target.
+	g = NewGame(&buf)
+	g.Config.Hammers = []Hammer{{Name: "wide", Spread: true, SplashAccuracy: 0}}
+	g.Init(4, 4)
+	g.HoleFactory.HoleSet.GetHole(4).OccupyingMole.ToggleState()
+	g.ProcessPlayerInput("whack 4")
+	assert.Equal(t, Stats{Whacks: 1, Hits: 1}, g.Stats)
+	assert.Equal(t, 1.0, g.Stats.Accuracy())
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MoleEvent is one change in a mole's life: "spawned", "housed", "left",
+// "exposed", "hidden" or "died".  Hole is 0 when the mole was underground.
+type MoleEvent struct {
+	Kind string
+	At   time.Duration
+	Hole int
+}
+
+// MoleHistory remembers what every mole has been up to and how quickly the
+// player reacted to exposed moles.  It is shared by every copy of a MoleSet.
+// Now reports the game time and is left nil by a MoleSet that isn't part of
+// a game, in which case every event happens at 0.  Only Limit events of
+// each mole are kept: the first, its spawn, and the latest after that.
+// Dropped counts the ones left out in between.
+type MoleHistory struct {
+	Events    map[int][]MoleEvent
+	Dropped   map[int]int
+	Reactions []time.Duration
+	Now       func() time.Duration
+	Limit     int
+}
+
+func NewMoleHistory() *MoleHistory {
+	return &MoleHistory{Events: make(map[int][]MoleEvent), Dropped: make(map[int]int), Limit: 64}
+}
+
+func (mh *MoleHistory) Add(m *Mole, kind string) {
+	if mh == nil {
+		return
+	}
+	e := MoleEvent{Kind: kind}
+	if mh.Now != nil {
+		e.At = mh.Now()
+	}
+	if m.HoleOccupied != nil {
+		e.Hole = m.HoleOccupied.ID
+	}
+	events := append(mh.Events[m.ID], e)
+	if mh.Limit > 0 && len(events) > mh.Limit {
+		events = append(events[:1], events[len(events)-mh.Limit+1:]...)
+		if mh.Dropped == nil {
+			mh.Dropped = make(map[int]int)
+		}
+		mh.Dropped[m.ID]++
+	}
+	mh.Events[m.ID] = events
+}
+
+// Whacked records the death of m and the reaction time to it, measured from
+// the start of its last exposure.
+func (mh *MoleHistory) Whacked(m *Mole) {
+	if mh == nil {
+		return
+	}
+	mh.Add(m, "died")
+	events := mh.Events[m.ID]
+	for i := len(events) - 2; i >= 0; i-- {
+		if events[i].Kind == "exposed" {
+			mh.Reactions = append(mh.Reactions, events[len(events)-1].At-events[i].At)
+			return
+		}
+	}
+}
+
+// ReactionBuckets are the upper bounds of the reaction time histogram; the
+// last bucket holds everything slower.
+var ReactionBuckets = []time.Duration{
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	750 * time.Millisecond,
+	time.Second,
+	1500 * time.Millisecond,
+	2 * time.Second,
+}
+
+func (mh *MoleHistory) Histogram() []int {
+	counts := make([]int, len(ReactionBuckets)+1)
+	for _, r := range mh.Reactions {
+		i := 0
+		for i < len(ReactionBuckets) && r > ReactionBuckets[i] {
+			i++
+		}
+		counts[i]++
+	}
+	return counts
+}
+
+func (g *Game) handleHistory(args []string) {
+	if len(args) == 0 {
+		g.respond("history.no_mole", nil)
+		return
+	}
+	id, err := strconv.Atoi(args[0])
+	events, ok := g.MoleFactory.MoleSet.History.Events[id]
+	if err != nil || !ok {
+		g.respond("history.unknown_mole", Fields{"mole": args[0]})
+		return
+	}
+	var items []Fields
+	for _, e := range events {
+		item := Fields{"at": e.At.Round(time.Millisecond), "event": word(e.Kind), "where": word("underground")}
+		if e.Hole != 0 {
+			item["hole"] = e.Hole
+			item["where"] = Text{Key: "word.hole", Fields: Fields{"hole": e.Hole}}
+		}
+		items = append(items, item)
+	}
+	if n := g.MoleFactory.MoleSet.History.Dropped[id]; n > 0 {
+		g.respondList("history.truncated", Fields{"mole": id, "dropped": n}, items)
+		return
+	}
+	g.respondList("history", Fields{"mole": id}, items)
+}
+
+// reportReactions shows how fast the player was across the game, as long
+// as they hit anything.
+func (g *Game) reportReactions() {
+	mh := g.MoleFactory.MoleSet.History
+	if mh == nil || len(mh.Reactions) == 0 {
+		return
+	}
+	var total time.Duration
+	fastest, slowest := mh.Reactions[0], mh.Reactions[0]
+	for _, r := range mh.Reactions {
+		total += r
+		fastest = min(fastest, r)
+		slowest = max(slowest, r)
+	}
+	f := Fields{
+		"count":   len(mh.Reactions),
+		"mean":    (total / time.Duration(len(mh.Reactions))).Round(time.Millisecond),
+		"fastest": fastest.Round(time.Millisecond),
+		"slowest": slowest.Round(time.Millisecond),
+	}
+	var items []Fields
+	lower := time.Duration(0)
+	for i, n := range mh.Histogram() {
+		label := fmt.Sprintf("%s+", lower)
+		if i < len(ReactionBuckets) {
+			label = fmt.Sprintf("%s-%s", lower, ReactionBuckets[i])
+			lower = ReactionBuckets[i]
+		}
+		items = append(items, Fields{"range": label, "count": n, "bar": strings.Repeat("#", n)})
+	}
+	g.record(EventRecord, "reactions", f, items)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoleHistory(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	clock := NewManualClock(time.Unix(0, 0))
+	g.Clock = clock
+	g.Config.Entropy = 0
+	g.Init(2, 1)
+	m := g.MoleFactory.MoleSet.Housed[1]
+
+	clock.Advance(time.Second)
+	m.ToggleState()
+	clock.Advance(500 * time.Millisecond)
+	m.ToggleState()
+	clock.Advance(time.Second)
+	m.ToggleState()
+	clock.Advance(300 * time.Millisecond)
+	g.ProcessPlayerInput("whack 1")
+
+	events := g.MoleFactory.MoleSet.History.Events[1]
+	assert.Equal(t, []MoleEvent{
+		{Kind: "spawned"},
+		{Kind: "housed", Hole: 1},
+		{Kind: "exposed", At: time.Second, Hole: 1},
+		{Kind: "hidden", At: 1500 * time.Millisecond, Hole: 1},
+		{Kind: "exposed", At: 2500 * time.Millisecond, Hole: 1},
+		{Kind: "died", At: 2800 * time.Millisecond, Hole: 1},
+	}, events)
+	assert.Equal(t, []time.Duration{300 * time.Millisecond}, g.MoleFactory.MoleSet.History.Reactions)
+}
+
+func TestHistoryCommand(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.Clock = NewManualClock(time.Unix(0, 0))
+	g.Config.Entropy = 0
+	g.Init(1, 2)
+	g.MoleFactory.MoleSet.Housed[1].ToggleState()
+
+	g.ProcessPlayerInput("history 1")
+	assert.Contains(t, buf.String(), "Mole 1:\n        0s  spawned (underground)\n        0s  housed (hole 1)\n        0s  exposed (hole 1)\n")
+
+	buf.Reset()
+	g.ProcessPlayerInput("history 2")
+	assert.Equal(t, "Mole 2:\n        0s  spawned (underground)\n> ", buf.String())
+
+	buf.Reset()
+	g.ProcessPlayerInput("history 9")
+	assert.Contains(t, buf.String(), "There's no mole 9!")
+	g.ProcessPlayerInput("history")
+	assert.Contains(t, buf.String(), "Which mole?")
+}
+
+func TestHistoryLimit(t *testing.T) {
+	mh := NewMoleHistory()
+	mh.Limit = 3
+	m := &Mole{ID: 1}
+	for _, kind := range []string{"spawned", "housed", "exposed", "hidden"} {
+		mh.Add(m, kind)
+	}
+	require.Len(t, mh.Events[1], 3)
+	assert.Equal(t, "spawned", mh.Events[1][0].Kind)
+	assert.Equal(t, "exposed", mh.Events[1][1].Kind)
+	assert.Equal(t, 1, mh.Dropped[1])
+
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.Config.Entropy = 0
+	g.Init(1, 1)
+	g.MoleFactory.MoleSet.History.Limit = 2
+	m = g.MoleFactory.MoleSet.Housed[1]
+	m.ToggleState()
+	m.ToggleState()
+	buf.Reset()
+	g.ProcessPlayerInput("history 1")
+	assert.Equal(t, "Mole 1 (2 older events after its spawn not kept):\n        0s  spawned (underground)\n        0s  hidden (hole 1)\n> ", buf.String())
+}
+
+func TestReactionHistogram(t *testing.T) {
+	mh := NewMoleHistory()
+	mh.Reactions = []time.Duration{100 * time.Millisecond, 250 * time.Millisecond, 900 * time.Millisecond, 5 * time.Second}
+	assert.Equal(t, []int{2, 0, 0, 1, 0, 0, 1}, mh.Histogram())
+}
+
+func TestReactionReportAtEnd(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	clock := NewManualClock(time.Unix(0, 0))
+	g.Clock = clock
+	g.Config.Entropy = 0
+	g.Init(2, 2)
+	for _, m := range g.MoleFactory.MoleSet.Housed {
+		m.ToggleState()
+	}
+	clock.Advance(200 * time.Millisecond)
+	g.ProcessPlayerInput("whack 1")
+	clock.Advance(time.Second)
+	g.ProcessPlayerInput("whack 2")
+	require.Equal(t, Won, g.Outcome)
+
+	out := buf.String()
+	assert.Contains(t, out, "Reaction times over 2 hits: mean 700ms, fastest 200ms, slowest 1.2s\n")
+	assert.Contains(t, out, "  0s-250ms     # 1\n")
+	assert.Contains(t, out, "  1s-1.5s      # 1\n")
+	assert.Contains(t, out, "  2s+           0\n")
+	assert.Less(t, strings.Index(out, "YOU WIN"), strings.Index(out, "Reaction times"))
+
+	buf.Reset()
+	g.Init(2, 2)
+	g.ProcessPlayerInput("quit")
+	assert.NotContains(t, buf.String(), "Reaction times")
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"math/bits"
+)
+
+// HoleIndex keeps the holes in ID order so that picking a hole and listing
+// them never depends on map iteration.  It is shared by every copy of a
+// HoleSet, so it has to be a pointer.
+//
+// Open holds the open holes in no particular order and pos remembers where
+// each one sits, so opening and closing a hole are constant time and so is
+// picking one at random.  The same holes are kept in a bitset for the
+// policies that care about hole order.
+type HoleIndex struct {
+	Holes    []*Hole
+	Open     []int
+	LastUsed map[int]int
+	Weights  map[int]float64
+	Selector HoleSelector
+	Items    ItemHooks
+	pos      []int
+	bits     holeBits
+	uses     int
+}
+
+func NewHoleIndex() *HoleIndex {
+	return &HoleIndex{LastUsed: make(map[int]int), Weights: make(map[int]float64)}
+}
+
+func (ix *HoleIndex) Register(h *Hole) {
+	for len(ix.Holes) < h.ID {
+		ix.Holes = append(ix.Holes, nil)
+		ix.pos = append(ix.pos, 0)
+	}
+	ix.Holes[h.ID-1] = h
+}
+
+func (ix *HoleIndex) Hole(id int) *Hole {
+	if id < 1 || id > len(ix.Holes) {
+		return nil
+	}
+	return ix.Holes[id-1]
+}
+
+func (ix *HoleIndex) IsOpen(id int) bool {
+	return id >= 1 && id <= len(ix.pos) && ix.pos[id-1] > 0
+}
+
+func (ix *HoleIndex) open(id int) {
+	if ix.IsOpen(id) {
+		return
+	}
+	for len(ix.pos) < id {
+		ix.pos = append(ix.pos, 0)
+	}
+	ix.Open = append(ix.Open, id)
+	ix.pos[id-1] = len(ix.Open)
+	ix.bits.set(id - 1)
+	if w, ok := ix.Selector.(openWatcher); ok {
+		w.Opened(ix, id)
+	}
+}
+
+func (ix *HoleIndex) close(id int) {
+	if !ix.IsOpen(id) {
+		return
+	}
+	i := ix.pos[id-1] - 1
+	last := ix.Open[len(ix.Open)-1]
+	ix.Open[i] = last
+	ix.pos[last-1] = i + 1
+	ix.Open = ix.Open[:len(ix.Open)-1]
+	ix.pos[id-1] = 0
+	ix.bits.clear(id - 1)
+}
+
+// NextOpen returns the lowest open hole numbered from or above, or 0.
+func (ix *HoleIndex) NextOpen(from int) int {
+	return ix.bits.next(max(from, 1)-1) + 1
+}
+
+// Touch records that a mole has just moved into hole id.
+func (ix *HoleIndex) Touch(id int) {
+	ix.uses++
+	ix.LastUsed[id] = ix.uses
+}
+
+// Weight is how attractive hole id is to the weighted policy, 1 unless set.
+func (ix *HoleIndex) Weight(id int) float64 {
+	if w, ok := ix.Weights[id]; ok {
+		return w
+	}
+	return 1
+}
+
+// Select picks one of the open holes, or returns 0 when none are open.
+// Without a selector the lowest numbered hole wins.
+func (ix *HoleIndex) Select() int {
+	if len(ix.Open) == 0 {
+		return 0
+	}
+	if ix.Selector == nil {
+		return ix.NextOpen(1)
+	}
+	return ix.Selector.Select(ix)
+}
+
+// holeBits is a two level bitset: a bit in summary is set when the matching
+// word of words has any bit set, which lets next skip over long runs of
+// closed holes 4096 at a time.
+type holeBits struct {
+	words   []uint64
+	summary []uint64
+}
+
+func (b *holeBits) set(i int) {
+	w := i >> 6
+	for len(b.words) <= w {
+		b.words = append(b.words, 0)
+	}
+	for len(b.summary) <= w>>6 {
+		b.summary = append(b.summary, 0)
+	}
+	b.words[w] |= 1 << (i & 63)
+	b.summary[w>>6] |= 1 << (w & 63)
+}
+
+func (b *holeBits) clear(i int) {
+	w := i >> 6
+	if w >= len(b.words) {
+		return
+	}
+	b.words[w] &^= 1 << (i & 63)
+	if b.words[w] == 0 {
+		b.summary[w>>6] &^= 1 << (w & 63)
+	}
+}
+
+// next returns the first set bit at or after i, or -1.
+func (b *holeBits) next(i int) int {
+	w := i >> 6
+	if w >= len(b.words) {
+		return -1
+	}
+	if rest := b.words[w] &^ (1<<(i&63) - 1); rest != 0 {
+		return w<<6 + bits.TrailingZeros64(rest)
+	}
+	w++
+	for s := w >> 6; s < len(b.summary); s++ {
+		mask := b.summary[s]
+		if s == w>>6 {
+			mask &^= 1<<(w&63) - 1
+		}
+		if mask != 0 {
+			word := s<<6 + bits.TrailingZeros64(mask)
+			return word<<6 + bits.TrailingZeros64(b.words[word])
+		}
+	}
+	return -1
+}
+
+// MoleIndex lists every mole by ID, so a tick can walk the moles in order
+// without sorting the MoleSet maps.  Like HoleIndex it is shared by every
+// copy of a MoleSet.
+type MoleIndex struct {
+	Moles []*Mole
+}
+
+func (ix *MoleIndex) Register(m *Mole) {
+	for len(ix.Moles) < m.ID {
+		ix.Moles = append(ix.Moles, nil)
+	}
+	ix.Moles[m.ID-1] = m
+}
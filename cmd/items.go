@@ -0,0 +1,239 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Items are the player's tools besides the hammer.  A trap sits in an empty
+// hole and catches the next mole to come up in it.  Bait sits in an empty
+// hole too, and the next mole to tunnel heads for it if it can.  A smoke
+// bomb goes off at once and drives every mole hiding in a hole and its
+// neighbours, or a range of holes, up into the open.
+var ItemNames = []string{"trap", "bait", "smoke"}
+
+// ItemHooks let the items on the board act as moles move.  The HoleIndex
+// holds them so every copy of the HoleSet sees the same items.
+type ItemHooks interface {
+	// Tunneling may pick the hole a tunneling mole comes up in.
+	Tunneling(m *Mole) *Hole
+	// Occupied is told about every mole that moves into a hole, and says
+	// whether the mole was caught there.
+	Occupied(h *Hole, m *Mole) bool
+}
+
+type Items struct {
+	Inventory map[string]int
+	Used      map[string]int
+	Placed    map[int]string
+	Caught    int
+	Lured     int
+	Smoked    int
+	g         *Game
+}
+
+func NewItems(g *Game, inventory map[string]int) *Items {
+	it := &Items{Inventory: make(map[string]int), Used: make(map[string]int), Placed: make(map[int]string), g: g}
+	for name, n := range inventory {
+		it.Inventory[name] = n
+	}
+	return it
+}
+
+// ParseItems reads an inventory such as "trap:2,smoke:1".
+func ParseItems(s string) (map[string]int, error) {
+	items := make(map[string]int)
+	if s == "" {
+		return items, nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		name, n, ok := strings.Cut(strings.TrimSpace(part), ":")
+		count, err := strconv.Atoi(n)
+		if !ok || err != nil || count < 0 || !isItem(name) {
+			return nil, fmt.Errorf("bad item %q", part)
+		}
+		items[name] = count
+	}
+	return items, nil
+}
+
+func isItem(name string) bool {
+	for _, n := range ItemNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// baited picks the lowest numbered open hole with bait in it out of ids,
+// or any open baited hole when ids is nil.
+func (it *Items) baited(ids []int) *Hole {
+	hs := &it.g.HoleFactory.HoleSet
+	for _, h := range hs.Index.Holes {
+		if it.Placed[h.ID] != "bait" || h.State != Unoccupied {
+			continue
+		}
+		if ids == nil || containsInt(ids, h.ID) {
+			return h
+		}
+	}
+	return nil
+}
+
+func containsInt(ids []int, id int) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (it *Items) Tunneling(m *Mole) *Hole {
+	return it.baited(nil)
+}
+
+// Occupied springs a trap or takes the bait in h.  A trapped mole is dead,
+// but the game is only checked for a win once the move is over.
+func (it *Items) Occupied(h *Hole, m *Mole) bool {
+	g := it.g
+	switch it.Placed[h.ID] {
+	case "bait":
+		delete(it.Placed, h.ID)
+		it.Lured++
+		g.emit("item.lured", Fields{"mole": m.ID, "hole": h.ID})
+	case "trap":
+		delete(it.Placed, h.ID)
+		it.Caught++
+		ms := m.ParentMoleSet
+		ms.RemoveHoused(m)
+		ms.AddDead(m)
+		m.State = Dead
+		ms.History.Add(m, "trapped")
+		h.ParentHoleSet.RemoveUnavailable(h)
+		h.ParentHoleSet.AddAvailable(h)
+		h.OccupyingMole = nil
+		h.State = Unoccupied
+		m.HoleOccupied = nil
+		g.emit("item.trapped", Fields{"mole": m.ID, "hole": h.ID})
+		return true
+	}
+	return false
+}
+
+// lure points a mole in the tunnels at a baited hole next to it, if there
+// is one.
+func (g *Game) lure(m *Mole, links []int) {
+	if g.Items == nil {
+		return
+	}
+	if h := g.Items.baited(links); h != nil {
+		m.Dest = h.ID
+	}
+}
+
+func (g *Game) handleItems() {
+	it := g.Items
+	if it == nil {
+		g.respond("items.none", nil)
+		return
+	}
+	var items []Fields
+	for _, name := range ItemNames {
+		var holes []string
+		for _, h := range g.HoleFactory.HoleSet.Index.Holes {
+			if it.Placed[h.ID] == name {
+				holes = append(holes, strconv.Itoa(h.ID))
+			}
+		}
+		var placed any = ""
+		if len(holes) > 0 {
+			placed = Text{Key: "word.set_in", Fields: Fields{"holes": strings.Join(holes, ", ")}}
+		}
+		items = append(items, Fields{"item": name, "left": it.Inventory[name], "placed": placed})
+	}
+	g.respondList("items", nil, items)
+}
+
+// take uses up one of the named item, or says the player has none.
+func (it *Items) take(name string) bool {
+	if it.Inventory[name] <= 0 {
+		it.g.respond("item.none", Fields{"item": name})
+		return false
+	}
+	it.Inventory[name]--
+	it.Used[name]++
+	return true
+}
+
+func (g *Game) handlePlace(name string, args []string) {
+	if g.Items == nil {
+		g.respond("items.none", nil)
+		return
+	}
+	h := g.targetHole(args, "item", Fields{"item": name})
+	if h == nil {
+		return
+	}
+	if h.State != Unoccupied || g.Items.Placed[h.ID] != "" {
+		g.respond("item.bad_hole", Fields{"item": name, "hole": h.ID})
+		return
+	}
+	if !g.Items.take(name) {
+		return
+	}
+	g.Items.Placed[h.ID] = name
+	g.respond("item.placed", Fields{"item": name, "hole": h.ID, "left": g.Items.Inventory[name]})
+}
+
+func (g *Game) handleSmoke(args []string) {
+	if g.Items == nil {
+		g.respond("items.none", nil)
+		return
+	}
+	if len(args) == 0 {
+		g.respond("item.no_region", nil)
+		return
+	}
+	region, err := g.parseRegion(args)
+	if err != nil {
+		g.respond("item.bad_region", Fields{"region": args[0]})
+		return
+	}
+	if !g.Items.take("smoke") {
+		return
+	}
+	smoked := 0
+	for _, h := range g.HoleFactory.HoleSet.Index.Holes {
+		m := h.OccupyingMole
+		if !region[h.ID] || m == nil || m.State != HidingAlive {
+			continue
+		}
+		m.ToggleState()
+		smoked++
+		key := "mole.appeared"
+		if m.Armored {
+			key = "mole.appeared_armored"
+		}
+		g.emit(key, Fields{"mole": m.ID, "hole": h.ID})
+	}
+	g.Items.Smoked += smoked
+	g.respond("item.smoked", Fields{"moles": smoked, "left": g.Items.Inventory["smoke"]})
+}
+
+func (g *Game) itemStats() {
+	it := g.Items
+	if it == nil {
+		return
+	}
+	g.respond("stats.items", Fields{
+		"traps":  it.Used["trap"],
+		"caught": it.Caught,
+		"baits":  it.Used["bait"],
+		"lured":  it.Lured,
+		"smokes": it.Used["smoke"],
+		"smoked": it.Smoked,
+	})
+}
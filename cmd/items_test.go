@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrap(t *testing.T) {
+	g, _, buf := newTestGame(3, 2, withItems(map[string]int{"trap": 1}))
+	g.ProcessPlayerInput("trap 1")
+	assert.Contains(t, buf.String(), "Can't put trap there")
+	g.ProcessPlayerInput("trap 3")
+	assert.Contains(t, buf.String(), "You set trap in hole 3. 0 left.\n")
+	g.ProcessPlayerInput("trap 3")
+	assert.Contains(t, buf.String(), "Can't put trap there")
+
+	// A new mole comes up in the first open hole, which is the trapped one.
+	m, err := g.MoleFactory.NewMole()
+	require.NoError(t, err)
+	assert.False(t, m.TryOccupy(&g.HoleFactory.HoleSet), "the mole is caught, not housed")
+	assert.Equal(t, Dead, m.State)
+	assert.Nil(t, m.HoleOccupied)
+	assert.Contains(t, g.MoleFactory.MoleSet.Dead, 3)
+	assert.Equal(t, Unoccupied, g.HoleFactory.HoleSet.GetHole(3).State)
+	assert.Contains(t, g.HoleFactory.HoleSet.Available, 3)
+	assert.Contains(t, buf.String(), "SNAP! mole 3 walked into the trap in hole 3!\n")
+	assert.Empty(t, g.Items.Placed)
+
+	g.ProcessPlayerInput("trap 3")
+	assert.Contains(t, buf.String(), "You're out of trap!\n")
+}
+
+func TestTrapWins(t *testing.T) {
+	g, _, buf := newTestGame(2, 1, withItems(map[string]int{"trap": 1}))
+	g.ProcessPlayerInput("trap 2")
+	m := g.MoleFactory.MoleSet.Housed[1]
+	m.HoleOccupied.Free()
+	assert.False(t, g.HoleFactory.HoleSet.GetHole(2).TryOccupy(m), "a trapped mole isn't in the hole")
+	assert.NotEqual(t, End, g.State, "the win waits for the tick to finish moving the moles")
+	g.ProcessTick()
+	assert.Equal(t, Won, g.Outcome)
+	assert.Contains(t, buf.String(), "YOU WIN")
+}
+
+func TestTrapCatchesMovedMoles(t *testing.T) {
+	for _, c := range []struct {
+		move, reply string
+		setup       func(g *Game)
+	}{
+		{"m tunnel 2", "[moles] mole 1 tunnels over", func(g *Game) { g.Config.Versus = 1 }},
+		{"move 1 2", "[debug] mole 1", func(g *Game) { g.Config.Debug = true }},
+	} {
+		g, _, buf := newTestGame(2, 1, func(g *Game) {
+			withItems(map[string]int{"trap": 1})(g)
+			c.setup(g)
+		})
+		g.ProcessPlayerInput("trap 2")
+		g.ProcessPlayerInput(c.move)
+		assert.Contains(t, buf.String(), "SNAP! mole 1 walked into the trap in hole 2!\n", c.move)
+		assert.NotContains(t, buf.String(), c.reply, c.move)
+		assert.Equal(t, Won, g.Outcome, c.move)
+	}
+}
+
+func TestBait(t *testing.T) {
+	g, _, buf := newTestGame(5, 2, withItems(map[string]int{"bait": 1}))
+	g.ProcessPlayerInput("bait 4")
+	m := g.MoleFactory.MoleSet.Housed[2]
+	m.Tunnel(&g.HoleFactory.HoleSet)
+	require.NotNil(t, m.HoleOccupied)
+	assert.Equal(t, 4, m.HoleOccupied.ID)
+	assert.Contains(t, buf.String(), "mole 2 went for the bait in hole 4.\n")
+	m.Tunnel(&g.HoleFactory.HoleSet)
+	assert.Equal(t, 2, m.HoleOccupied.ID)
+}
+
+func TestBaitInTunnels(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.Config.Entropy = 0
+	g.Config.Items = map[string]int{"bait": 1}
+	g.Config.Tunnels = Tunnels{Topology: "ring", EdgeTicks: 0}
+	g.Init(6, 1)
+	g.ProcessPlayerInput("bait 6")
+	m := g.MoleFactory.MoleSet.Housed[1]
+	g.burrow(m)
+	require.NotNil(t, m.HoleOccupied)
+	assert.Equal(t, 6, m.HoleOccupied.ID)
+}
+
+func TestSmoke(t *testing.T) {
+	g, _, buf := newTestGame(6, 6, withItems(map[string]int{"smoke": 1}))
+	g.MoleFactory.MoleSet.Housed[3].ToggleState()
+	g.ProcessPlayerInput("smoke 2-4")
+	assert.Contains(t, buf.String(), "POOF! 2 moles smoked out. 0 smoke bombs left.\n")
+	for id := 2; id <= 4; id++ {
+		assert.Equal(t, ExposedAlive, g.MoleFactory.MoleSet.Housed[id].State)
+	}
+	assert.Equal(t, HidingAlive, g.MoleFactory.MoleSet.Housed[5].State)
+	g.ProcessPlayerInput("smoke 1")
+	assert.Contains(t, buf.String(), "You're out of smoke!\n")
+}
+
+func TestItemTargets(t *testing.T) {
+	g, _, buf := newTestGame(6, 2, withItems(map[string]int{"trap": 1, "smoke": 1}))
+	g.ProcessPlayerInput("trap")
+	assert.Contains(t, buf.String(), "Where should the trap go? Give a hole number.\n")
+	g.ProcessPlayerInput("trap 9")
+	assert.Contains(t, buf.String(), "There's no hole 9 to put trap in!\n")
+	g.ProcessPlayerInput("smoke")
+	assert.Contains(t, buf.String(), "Smoke out where? Give a hole or a range like 2-5.\n")
+
+	g.ProcessPlayerInput("smoke 50")
+	assert.Contains(t, buf.String(), "Can't smoke out 50, give a hole or a range like 2-5.\n")
+	assert.Equal(t, 1, g.Items.Inventory["smoke"], "smoke over no holes isn't used up")
+	g.ProcessPlayerInput("smoke 1-999999999")
+	assert.Contains(t, buf.String(), "POOF! 2 moles smoked out. 0 smoke bombs left.\n")
+}
+
+func TestItemsAndStats(t *testing.T) {
+	g, _, buf := newTestGame(4, 2, withItems(map[string]int{"trap": 2, "bait": 1, "smoke": 1}))
+	g.ProcessPlayerInput("trap 3")
+	g.ProcessPlayerInput("items")
+	assert.Contains(t, buf.String(), "Items:\n  trap: 1 left, set in hole 3\n  bait: 1 left\n  smoke: 1 left\n")
+	g.ProcessPlayerInput("smoke 1")
+	m := g.MoleFactory.MoleSet.Housed[2]
+	m.HoleOccupied.Free()
+	g.HoleFactory.HoleSet.GetHole(3).TryOccupy(m)
+	g.ProcessPlayerInput("stats")
+	assert.Contains(t, buf.String(), "Traps: 1 set, 1 caught  Bait: 0 set, 0 lured  Smoke: 1 used, 2 smoked out\n")
+
+	g, _, buf = newTestGame(2, 1, withItems(nil))
+	g.ProcessPlayerInput("trap 2")
+	assert.Contains(t, buf.String(), "You don't have any items this game.\n")
+}
+
+func TestParseItems(t *testing.T) {
+	items, err := ParseItems("trap:2, smoke:0")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"trap": 2, "smoke": 0}, items)
+	_, err = ParseItems("rocket:1")
+	assert.EqualError(t, err, `bad item "rocket:1"`)
+	_, err = ParseItems("trap")
+	assert.Error(t, err)
+}
+
+func withItems(items map[string]int) func(g *Game) {
+	return func(g *Game) {
+		g.Config.Entropy = 0
+		g.Config.Items = items
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// catalogs holds the messages for every language the game speaks.  English
+// is the reference: every other catalog should have the same keys, and any
+// it is missing fall back to English.
+var catalogs = map[string]map[string]string{
+	"en": messages,
+	"es": messagesES,
+}
+
+func Languages() []string {
+	var langs []string
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Catalog looks up messages in one language.  A nil Catalog is English.
+type Catalog struct {
+	Lang     string
+	messages map[string]string
+}
+
+func NewCatalog(lang string) (*Catalog, error) {
+	m, ok := catalogs[lang]
+	if !ok {
+		return nil, fmt.Errorf("unknown language %q, try one of %s", lang, strings.Join(Languages(), ", "))
+	}
+	return &Catalog{Lang: lang, messages: m}, nil
+}
+
+// Message finds the template for key, falling back to English when the
+// catalog doesn't have it.
+func (c *Catalog) Message(key string) (string, bool) {
+	if c != nil {
+		if tmpl, ok := c.messages[key]; ok {
+			return tmpl, true
+		}
+	}
+	tmpl, ok := messages[key]
+	return tmpl, ok
+}
+
+// Text fills in the message named by t, or gives back the key itself if no
+// catalog has it.
+func (c *Catalog) Text(t Text) string {
+	tmpl, ok := c.Message(t.Key)
+	if !ok {
+		return t.Key
+	}
+	return formatWith(tmpl, t.Fields, c.Text)
+}
+
+// Text is a field value that is a message of its own, such as the state of
+// a hole, so it gets translated along with the message it goes into.
+type Text struct {
+	Key    string
+	Fields Fields
+}
+
+func (t Text) String() string {
+	return (*Catalog)(nil).Text(t)
+}
+
+// word is the Text for a single word or short phrase kept under "word.".
+func word(s string) Text {
+	return Text{Key: "word." + s}
+}
+
+// MissingKeys lists the English keys the catalog for lang has no
+// translation for.
+func MissingKeys(lang string) []string {
+	var missing []string
+	for key := range messages {
+		if _, ok := catalogs[lang][key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// placeholders lists the field names used in tmpl.
+func placeholders(tmpl string) []string {
+	var names []string
+	for {
+		open := strings.IndexByte(tmpl, '{')
+		if open < 0 {
+			break
+		}
+		close := strings.IndexByte(tmpl[open:], '}')
+		if close < 0 {
+			break
+		}
+		name, _, _ := strings.Cut(tmpl[open+1:open+close], ":")
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+		tmpl = tmpl[open+close+1:]
+	}
+	sort.Strings(names)
+	return names
+}
+
+// runLang checks the catalogs: it lists the keys each language is missing
+// and the translations whose placeholders don't match the English ones.
+func runLang(args []string, stdout io.Writer, stderr io.Writer) int {
+	fs := flag.NewFlagSet("wam lang", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	lang := fs.String("lang", "", "only check this language")
+	if err := fs.Parse(args); err != nil {
+		return ExitError
+	}
+	langs := Languages()
+	if *lang != "" {
+		if _, err := NewCatalog(*lang); err != nil {
+			fmt.Fprintf(stderr, "%v\n", err)
+			return ExitError
+		}
+		langs = []string{*lang}
+	}
+	problems := 0
+	for _, l := range langs {
+		missing := MissingKeys(l)
+		var mismatched []string
+		for key, tmpl := range catalogs[l] {
+			en, ok := messages[key]
+			if ok && !slices.Equal(placeholders(en), placeholders(tmpl)) {
+				mismatched = append(mismatched, key)
+			}
+		}
+		sort.Strings(mismatched)
+		fmt.Fprintf(stdout, "%s: %d of %d messages translated\n", l, len(messages)-len(missing), len(messages))
+		for _, key := range missing {
+			fmt.Fprintf(stdout, "  untranslated: %s\n", key)
+		}
+		for _, key := range mismatched {
+			fmt.Fprintf(stdout, "  placeholders differ: %s\n", key)
+		}
+		problems += len(missing) + len(mismatched)
+	}
+	if problems > 0 {
+		return ExitError
+	}
+	return 0
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCatalogsComplete(t *testing.T) {
+	assert.Equal(t, []string{"en", "es"}, Languages())
+	for _, lang := range Languages() {
+		assert.Empty(t, MissingKeys(lang), lang)
+		for key, tmpl := range catalogs[lang] {
+			assert.Equal(t, placeholders(messages[key]), placeholders(tmpl), "%s %s", lang, key)
+		}
+	}
+}
+
+func TestWordsHaveMessages(t *testing.T) {
+	var words []string
+	for _, o := range []Outcome{Undecided, Won, Lost, Quit} {
+		words = append(words, o.String())
+	}
+	for _, o := range []WhackOutcome{Whiff, Miss, Hit, Deflected} {
+		words = append(words, o.Name())
+	}
+	words = append(words, "harder", "easier", "hold")
+	words = append(words, "spawned", "housed", "left", "exposed", "hidden", "trapped", "died")
+	words = append(words, "classic", "survival", "locked", "open", "collapsed", "blocked", "empty", "hiding")
+	for _, w := range words {
+		assert.Contains(t, messages, word(w).Key)
+	}
+}
+
+func TestCatalogFallback(t *testing.T) {
+	_, err := NewCatalog("xx")
+	assert.Error(t, err)
+
+	c := &Catalog{Lang: "test", messages: map[string]string{"word.ready": "prêt"}}
+	tmpl, ok := c.Message("word.ready")
+	assert.True(t, ok)
+	assert.Equal(t, "prêt", tmpl)
+	tmpl, ok = c.Message("word.empty")
+	assert.True(t, ok)
+	assert.Equal(t, "empty", tmpl)
+	_, ok = c.Message("no.such.key")
+	assert.False(t, ok)
+	assert.Equal(t, "no.such.key", c.Text(Text{Key: "no.such.key"}))
+	assert.Equal(t, "hole 4", Text{Key: "word.hole", Fields: Fields{"hole": 4}}.String())
+
+	var buf bytes.Buffer
+	r := &TextRenderer{Out: &buf, Catalog: c}
+	r.Render(Record{Key: "hammer.list", Fields: Fields{"current": "mallet"}, Items: []Fields{{"hammer": "mallet", "cooldown": 0, "status": word("ready")}}})
+	assert.Equal(t, "Hammers (holding the mallet):\n  mallet: cooldown 0, prêt\n", buf.String())
+}
+
+func TestTextFieldsTranslated(t *testing.T) {
+	es, err := NewCatalog("es")
+	require.NoError(t, err)
+	status := Text{Key: "word.mole_status", Fields: Fields{"mole": 2, "status": word("exposed")}}
+	rec := Record{Key: "spectate.board", Items: []Fields{{"hole": 1, "status": status}}}
+
+	var buf bytes.Buffer
+	(&TextRenderer{Out: &buf, Catalog: es}).Render(rec)
+	assert.Equal(t, "Tablero:\n  agujero 1: topo 2 fuera\n", buf.String())
+
+	buf.Reset()
+	(&JSONRenderer{Out: &buf}).Render(rec)
+	var got jsonRecord
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+	assert.Equal(t, "mole 2 exposed", got.Items[0]["status"])
+}
+
+func TestRunWithLangFlag(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "moves.txt")
+	require.NoError(t, os.WriteFile(script, []byte("@1s holes\n@1s quit\n"), 0o644))
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-script", script, "-lang", "es"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, ExitQuit, code)
+	assert.Contains(t, stdout.String(), "agujero: 1 (abierto)")
+	assert.Contains(t, stdout.String(), "¡ADIÓS, RENDIDO!")
+
+	code = run([]string{"-lang", "xx"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr.String(), `unknown language "xx"`)
+
+	stdout.Reset()
+	code = run([]string{"lang"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout.String(), fmt.Sprintf("es: %d of %d messages translated", len(messages), len(messages)))
+}
+
+func TestRunLangListsUntranslated(t *testing.T) {
+	catalogs["test"] = map[string]string{"game.won": "gagné {score}\n"}
+	defer delete(catalogs, "test")
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"lang", "-lang", "test"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, ExitError, code)
+	out := stdout.String()
+	assert.Contains(t, out, fmt.Sprintf("test: 1 of %d messages translated\n", len(messages)))
+	assert.Contains(t, out, "  untranslated: game.help\n")
+	assert.NotContains(t, out, "untranslated: game.won\n")
+	assert.Contains(t, out, "  placeholders differ: game.won\n")
+}
@@ -2,37 +2,100 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"io"
 	"math/rand"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 )
 
 const WelcomeMessage = `
-|||=======MOLES MOLES MOLES MOLES=======|||\n
-Welcome to a wonderful game of moles. It's quite simple:\n
-There are holes which can be whacked and there are moles which need to be whacked!\n
-Whack all the moles! GO!!!!!\n\n
+|||=======MOLES MOLES MOLES MOLES=======|||
+Welcome to a wonderful game of moles. It's quite simple:
+There are holes which can be whacked and there are moles which need to be whacked!
+Whack all the moles! GO!!!!!
+
 `
 
 const HelpMessage = `
-|||=======HELP HELP HELP HELP HELP=======|||\n
+|||=======HELP HELP HELP HELP HELP=======|||
 The name of the game is to whack all of the moles:
 
 Commands:
 - whack [#]
 	Attempt to whack a mole on hole #.  If a mole is there and is exposed then the whack will be successful and the mole will be removed from the game.
+- hammer [name]
+	Switch to another hammer, or list your hammers when no name is given.  The mallet hits one hole, the wide hammer also swings at the neighbouring holes but may glance off them, and the slow heavy hammer is the only one that gets through armor.  Each hammer needs time to cool down between swings.
+- stats
+	Show your whacking record and score, overall and for each hammer.  Hits in quick succession build a streak that multiplies the points for each hit, a long enough streak sets off a frenzy bonus and any swing that hits nothing breaks the streak.
+- peek [# | #-#]
+	Sweep the radar over every hole, the hole # and its neighbours, or a range of holes.  Shows which holes hide a mole and which moles are exposed, and keeps that part of the board in view through the next tick.  The radar has limited charges and needs to warm up between peeks.
+- plug [#]
+	Block hole # with a plug so no mole can use it.  Only empty holes can be plugged and you only have a few plugs.
+- unplug [#]
+	Pull the plug out of hole # and put it back in your pocket.
+- items
+	List your items and where you have set them.
+- trap [#]
+	Set a trap in empty hole #.  The next mole to come up there is caught.
+- bait [#]
+	Put bait in empty hole #.  The next mole to tunnel heads for it.
+- smoke [# | #-#]
+	Set off a smoke bomb over hole # and its neighbours, or a range of holes.  Every mole hiding there pops up.
+- map
+	Draw the tunnels between the holes.  Moles can only travel along tunnels, so look for the holes they have to pass through.
+- history [#]
+	Look back over everything mole # has done, from the moment it was born.
+- difficulty
+	Show how lively the moles are.  With adaptive difficulty on this also lists every change made to keep you near the target hit rate.
+- achievements
+	Show the goals that carry over from game to game, which ones you have unlocked and how close you are to the rest.
+- pause
+	Pause the game.  The moles stay put and the clock stops until you resume.  The game also pauses itself if you go quiet for too long.
+- resume
+	Carry on after a pause.
 - moles
 	Survey the moles.  Returns information about how many moles are left.
 - holes
 	Survey the holes.  Returns information about all the spots that can be whacked.
 - quit
 	Quits the game.
+
+Versus mode:
+When a second player is controlling moles they type their commands on the same terminal, starting each one with "m".  Commands that take a mole # use the lowest numbered mole still alive when it is left out.
+- m moles
+	List your moles and where they are.
+- m hide [mole #]
+	Duck back down into the hole.
+- m expose [mole #]
+	Pop up out of the hole.  Cheeky, but the whacker can get you.
+- m tunnel [hole #] [mole #]
+	Move to another empty hole.  On a tunnel network the holes have to be linked and the trip takes a while.
+- m peek-hammer
+	See which hammer the whacker is holding, whether it is ready and where they last swung.
 - help
 	You are here.  Type this again and you will be here again.
+
+Debug commands:
+Only with -debug, and never in a ranked game.
+- spawn [n]
+	Add n new moles, in holes if there is room.
+- expose [mole #] / hide [mole #]
+	Pop a mole up or send it down.
+- move [mole #] [hole #]
+	Put a mole straight into an empty hole.
+- freeze
+	Stop or restart the ticks.  The clock keeps running.
+- step
+	Run one tick, even when frozen.
+- dump
+	List every hole and mole with the sets they are in.
+- seed [n]
+	Show the random seed, or reseed with n.
 `
 
 type HoleState int
@@ -50,6 +113,8 @@ type MoleFactory struct {
 const (
 	Unoccupied HoleState = iota
 	Occupied
+	Collapsed
+	Blocked
 )
 
 const (
@@ -64,26 +129,29 @@ type Hole struct {
 	State         HoleState
 	OccupyingMole *Mole
 	ParentHoleSet HoleSet
+	ReopenTick    int
 }
 
 type HoleSet struct {
 	Available   map[int]*Hole
 	Unavailable map[int]*Hole
+	Collapsed   map[int]*Hole
+	Blocked     map[int]*Hole
+	Index       *HoleIndex
 }
 
 type MoleSet struct {
 	Housed   map[int]*Mole
 	Unhoused map[int]*Mole
 	Dead     map[int]*Mole
+	Index    *MoleIndex
+	History  *MoleHistory
 }
 
 func (hs *HoleSet) PrintHolesString() string {
 	var b strings.Builder
 
-	for _, ho := range hs.Available {
-		fmt.Fprintf(&b, "hole: %d\n", ho.ID)
-	}
-	for _, ho := range hs.Unavailable {
+	for _, ho := range hs.Index.Holes {
 		fmt.Fprintf(&b, "hole: %d\n", ho.ID)
 	}
 
@@ -94,16 +162,28 @@ func (ms *MoleSet) GetMoleStats() string {
 	return fmt.Sprintf("Alive: %d\nDead: %d\n", len(ms.Housed)+len(ms.Unhoused), len(ms.Dead))
 }
 
-func (hs *HoleSet) GetHole(id int) *Hole {
-	if h, ok := hs.Available[id]; ok {
-		return h
+func sortedMoles(set map[int]*Mole) []*Mole {
+	moles := make([]*Mole, 0, len(set))
+	for _, m := range set {
+		moles = append(moles, m)
 	}
+	sort.Slice(moles, func(i, j int) bool { return moles[i].ID < moles[j].ID })
+	return moles
+}
 
-	if h, ok := hs.Unavailable[id]; ok {
-		return h
+func sortedHoles(sets ...map[int]*Hole) []*Hole {
+	var holes []*Hole
+	for _, set := range sets {
+		for _, h := range set {
+			holes = append(holes, h)
+		}
 	}
+	sort.Slice(holes, func(i, j int) bool { return holes[i].ID < holes[j].ID })
+	return holes
+}
 
-	return nil
+func (hs *HoleSet) GetHole(id int) *Hole {
+	return hs.Index.Hole(id)
 }
 
 func (ms *MoleSet) addToMap(m map[int]*Mole, mo *Mole) error {
@@ -147,11 +227,16 @@ func (hs *HoleSet) addToMap(m map[int]*Hole, h *Hole) error {
 }
 
 func (hs *HoleSet) AddAvailable(h *Hole) error {
-	return hs.addToMap(hs.Available, h)
+	if err := hs.addToMap(hs.Available, h); err != nil {
+		return err
+	}
+	hs.Index.open(h.ID)
+	return nil
 }
 
 func (hs *HoleSet) RemoveAvailable(h *Hole) {
 	delete(hs.Available, h.ID)
+	hs.Index.close(h.ID)
 }
 
 func (hs *HoleSet) AddUnavailable(h *Hole) error {
@@ -165,6 +250,7 @@ func (hs *HoleSet) RemoveUnavailable(h *Hole) {
 func (f *HoleFactory) NewHole() (*Hole, error) {
 	f.HoleId++
 	h := &Hole{ID: f.HoleId, State: Unoccupied, ParentHoleSet: f.HoleSet}
+	f.HoleSet.Index.Register(h)
 	err := f.HoleSet.AddAvailable(h)
 	if err != nil {
 		return nil, err
@@ -177,6 +263,9 @@ func NewHoleFactory() *HoleFactory {
 		HoleSet: HoleSet{
 			Available:   make(map[int]*Hole),
 			Unavailable: make(map[int]*Hole),
+			Collapsed:   make(map[int]*Hole),
+			Blocked:     make(map[int]*Hole),
+			Index:       NewHoleIndex(),
 		},
 	}
 }
@@ -187,6 +276,8 @@ func NewMoleFactory() *MoleFactory {
 			Housed:   make(map[int]*Mole),
 			Unhoused: make(map[int]*Mole),
 			Dead:     make(map[int]*Mole),
+			Index:    &MoleIndex{},
+			History:  NewMoleHistory(),
 		},
 	}
 }
@@ -196,31 +287,46 @@ type Mole struct {
 	State         MoleState
 	HoleOccupied  *Hole
 	ParentMoleSet MoleSet
+	Armored       bool
+	Node          int
+	Dest          int
+	TravelTicks   int
+	ExposedTicks  int
+	Controlled    bool
 }
 
 func (f *MoleFactory) NewMole() (*Mole, error) {
 	f.MoleId++
 	m := &Mole{ID: f.MoleId, State: TunnelingAlive, ParentMoleSet: f.MoleSet}
+	f.MoleSet.Index.Register(m)
 	err := f.MoleSet.AddUnhoused(m)
 	if err != nil {
 		return nil, err
 	}
+	f.MoleSet.History.Add(m, "spawned")
 	return m, nil
 }
 
+// TryOccupy moves m into h if it is free, and says whether the mole is now
+// in it.  A mole caught by a trap on the way in isn't.
 func (h *Hole) TryOccupy(m *Mole) bool {
-	if h.State == Occupied {
+	if h.State != Unoccupied || m.State == Dead {
 		return false
 	}
 
 	h.ParentHoleSet.RemoveAvailable(h)
 	h.ParentHoleSet.AddUnavailable(h)
+	h.ParentHoleSet.Index.Touch(h.ID)
 	m.ParentMoleSet.RemoveUnhoused(m)
 	m.ParentMoleSet.AddHoused(m)
 	h.OccupyingMole = m
 	m.HoleOccupied = h
 	m.State = HidingAlive
 	h.State = Occupied
+	m.ParentMoleSet.History.Add(m, "housed")
+	if it := h.ParentHoleSet.Index.Items; it != nil && it.Occupied(h, m) {
+		return false
+	}
 	return true
 }
 
@@ -228,6 +334,7 @@ func (h *Hole) Free() {
 	h.ParentHoleSet.AddAvailable(h)
 	h.ParentHoleSet.RemoveUnavailable(h)
 	m := h.OccupyingMole
+	m.ParentMoleSet.History.Add(m, "left")
 	m.ParentMoleSet.AddUnhoused(m)
 	m.ParentMoleSet.RemoveHoused(m)
 	h.OccupyingMole.HoleOccupied = nil
@@ -245,6 +352,11 @@ func (m *Mole) Tunnel(hs *HoleSet) {
 		m.HoleOccupied.Free()
 	}
 	m.State = TunnelingAlive
+	if it := hs.Index.Items; it != nil {
+		if h := it.Tunneling(m); h != nil && m.Occupy(h) {
+			return
+		}
+	}
 	m.TryOccupy(hs)
 }
 
@@ -252,30 +364,84 @@ func (m *Mole) ToggleState() {
 	switch m.State {
 	case HidingAlive:
 		m.State = ExposedAlive
+		m.ParentMoleSet.History.Add(m, "exposed")
 	case ExposedAlive:
 		m.State = HidingAlive
+		m.ParentMoleSet.History.Add(m, "hidden")
 	}
 }
 
-func (h *Hole) TryWhack() (bool, string) {
-	if h.State == Unoccupied {
-		return false, "whiff, no moles here!\n"
+type WhackOutcome int
+
+const (
+	Whiff WhackOutcome = iota
+	Miss
+	Hit
+	Deflected
+)
+
+func (h *Hole) Whack() WhackOutcome {
+	return h.WhackWith(false)
+}
+
+// WhackWith whacks the hole with a hammer that can or cannot get through a
+// mole's armor.
+func (h *Hole) WhackWith(piercing bool) WhackOutcome {
+	if h.State != Occupied {
+		return Whiff
+	}
+
+	m := h.OccupyingMole
+	if m.TryWhackWith(piercing) {
+		return Hit
+	}
+	if m.Armored && m.State == ExposedAlive {
+		return Deflected
 	}
 
-	if h.OccupyingMole.TryWhack() {
-		return true, "bonked out of existence!\n"
+	return Miss
+}
+
+func (o WhackOutcome) Response() string {
+	switch o {
+	case Hit:
+		return "bonked out of existence!\n"
+	case Miss:
+		return "missed and now its laughing!\n"
+	case Deflected:
+		return "CLANG! the armor held!\n"
+	default:
+		return "whiff, no moles here!\n"
 	}
+}
 
-	return false, "missed and now its laughing!\n"
+func (h *Hole) TryWhack() (bool, string) {
+	o := h.Whack()
+	return o == Hit, o.Response()
 }
 
 func (m *Mole) TryWhack() bool {
+	return m.TryWhackWith(false)
+}
+
+func (m *Mole) TryWhackWith(piercing bool) bool {
 	if m.State != ExposedAlive {
 		return false
 	}
+	if m.Armored && !piercing {
+		return false
+	}
 	m.ParentMoleSet.RemoveHoused(m)
 	m.ParentMoleSet.AddDead(m)
 	m.State = Dead
+	m.ParentMoleSet.History.Whacked(m)
+	if h := m.HoleOccupied; h != nil {
+		h.ParentHoleSet.RemoveUnavailable(h)
+		h.ParentHoleSet.AddAvailable(h)
+		h.OccupyingMole = nil
+		h.State = Unoccupied
+		m.HoleOccupied = nil
+	}
 	return true
 }
 
@@ -283,13 +449,11 @@ func (m *Mole) GetAvailableHole(hs *HoleSet) *Hole {
 	if m.State == Dead {
 		return nil
 	}
-	if len(hs.Available) < 1 {
+	id := hs.Index.Select()
+	if id == 0 {
 		return nil
 	}
-	for _, h := range hs.Available {
-		return h
-	}
-	return nil
+	return hs.Available[id]
 }
 
 func (m *Mole) TryOccupy(hs *HoleSet) bool {
@@ -308,12 +472,145 @@ const (
 	End
 )
 
+type Outcome int
+
+const (
+	Undecided Outcome = iota
+	Won
+	Lost
+	Quit
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case Won:
+		return "won"
+	case Lost:
+		return "lost"
+	case Quit:
+		return "quit"
+	}
+	return "undecided"
+}
+
+// Exit statuses reported by the process so scripted runs can tell how a game
+// finished without reading its output.
+const (
+	ExitWin   = 0
+	ExitLose  = 1
+	ExitQuit  = 2
+	ExitError = 3
+)
+
+// Stats counts whacks by outcome.  A whack deflected by armor is a miss and
+// is also counted in Deflected.
+type Stats struct {
+	Whacks    int
+	Hits      int
+	Misses    int
+	Whiffs    int
+	Deflected int
+}
+
+func (s *Stats) Record(o WhackOutcome) {
+	s.Whacks++
+	switch o {
+	case Hit:
+		s.Hits++
+	case Miss:
+		s.Misses++
+	case Deflected:
+		s.Misses++
+		s.Deflected++
+	default:
+		s.Whiffs++
+	}
+}
+
+func (s *Stats) Accuracy() float64 {
+	if s.Whacks == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Whacks)
+}
+
+// Config holds the rules of a game.  PeekCharges below zero means the radar
+// never runs out; with Fog on, moles only show themselves while a peek is
+// active.
+type Config struct {
+	Entropy      int
+	Tick         time.Duration
+	TimeLimit    time.Duration
+	ArmoredMoles int
+	Hammers      []Hammer
+	PeekCharges  int
+	PeekCooldown time.Duration
+	Fog          bool
+	Breeding     Breeding
+	Terrain      Terrain
+	Tunnels      Tunnels
+	Selection    string
+	HoleWeights  map[int]float64
+	EventLimit   int
+	IdleTimeout  time.Duration
+	Scoring      ScoreRules
+	ExposeTicks  int
+	Adaptive     AdaptiveRules
+	Versus       int
+	Mode         string
+	Debug        bool
+	Items        map[string]int
+	BoardEvents  BoardEvents
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Entropy:      30,
+		Tick:         time.Second,
+		Hammers:      DefaultHammers(),
+		PeekCharges:  3,
+		PeekCooldown: 5 * time.Second,
+		EventLimit:   100,
+		Scoring:      DefaultScoreRules(),
+		BoardEvents:  DefaultBoardEvents(),
+	}
+}
+
 type Game struct {
-	HoleFactory  *HoleFactory
-	MoleFactory  *MoleFactory
-	State        GameState
-	Output       io.Writer
-	WinCondition int
+	HoleFactory    *HoleFactory
+	MoleFactory    *MoleFactory
+	State          GameState
+	Output         io.Writer
+	Renderer       Renderer
+	WinCondition   int
+	Config         Config
+	Outcome        Outcome
+	Clock          Clock
+	Rand           *rand.Rand
+	Seed           int64
+	StartTime      time.Time
+	Ticks          int
+	Stats          Stats
+	Armory         *Armory
+	Radar          *Radar
+	Plugs          int
+	Tunnels        *TunnelGraph
+	Combo          Combo
+	Difficulty     *Difficulty
+	Achievements   *Achievements
+	Survived       int
+	Replay         *Replay
+	Items          *Items
+	Frozen         bool
+	FogUntil       int
+	LastBoardEvent int
+	stepping       bool
+	Paused         bool
+	PausedAt       time.Time
+	PausedFor      time.Duration
+	LastInput      time.Time
+	batching       bool
+	pending        []Record
 }
 
 // make holes
@@ -330,23 +627,73 @@ func (g *Game) MakeMoles(moles int) {
 }
 
 func (g *Game) HouseMoles() {
-	for _, m := range g.MoleFactory.MoleSet.Unhoused {
-		_ = m.TryOccupy(&g.HoleFactory.HoleSet)
+	ms := &g.MoleFactory.MoleSet
+	for _, m := range ms.Index.Moles {
+		if _, ok := ms.Unhoused[m.ID]; ok {
+			_ = m.TryOccupy(&g.HoleFactory.HoleSet)
+		}
 	}
 }
 
 func NewGame(out io.Writer) *Game {
 	hf := &HoleFactory{}
 	mf := &MoleFactory{}
-	return &Game{HoleFactory: hf, MoleFactory: mf, State: Initializing, Output: out}
+	seed := time.Now().UnixNano()
+	return &Game{
+		HoleFactory: hf,
+		MoleFactory: mf,
+		State:       Initializing,
+		Output:      out,
+		Renderer:    &TextRenderer{Out: out},
+		Config:      DefaultConfig(),
+		Clock:       realClock{},
+		Rand:        rand.New(rand.NewSource(seed)),
+		Seed:        seed,
+	}
 }
 func (g *Game) Init(holes int, moles int) {
+	g.StartTime = g.Clock.Now()
+	g.LastInput = g.StartTime
+	g.Paused = false
+	g.PausedFor = 0
+	g.Combo = Combo{}
+	g.Survived = 0
+	g.FogUntil = 0
+	g.LastBoardEvent = 0
 	g.WinCondition = moles
 	g.HoleFactory = NewHoleFactory()
+	g.Items = nil
+	if len(g.Config.Items) > 0 {
+		g.Items = NewItems(g, g.Config.Items)
+		g.HoleFactory.HoleSet.Index.Items = g.Items
+	}
+	if g.Config.Selection != "" {
+		g.HoleFactory.HoleSet.Index.Selector, _ = NewHoleSelector(g.Config.Selection, g.Rand)
+	}
+	for id, w := range g.Config.HoleWeights {
+		g.HoleFactory.HoleSet.Index.Weights[id] = w
+	}
 	g.MakeHoles(holes)
 	g.MoleFactory = NewMoleFactory()
+	g.MoleFactory.MoleSet.History.Now = g.Elapsed
 	g.MakeMoles(moles)
+	g.ControlMoles(g.Config.Versus)
+	g.ArmorMoles(g.Config.ArmoredMoles)
 	g.HouseMoles()
+	g.Armory = NewArmory(g.Config.Hammers)
+	g.Radar = NewRadar(g.Config.PeekCharges)
+	g.Plugs = g.Config.Terrain.Plugs
+	g.Difficulty = nil
+	if g.Config.Adaptive.TargetHitRate > 0 {
+		g.Difficulty = &Difficulty{Rules: g.Config.Adaptive}
+		if g.Config.ExposeTicks == 0 {
+			g.Config.ExposeTicks = g.Config.Adaptive.MaxExposeTicks
+		}
+	}
+	g.Tunnels = nil
+	if g.Config.Tunnels.Topology != "" {
+		g.Tunnels, _ = BuildTunnelGraph(g.Config.Tunnels, holes, g.Rand)
+	}
 }
 
 func (g *Game) CheckWin(moles int) bool {
@@ -357,12 +704,24 @@ func (g *Game) CheckWin(moles int) bool {
 	return false
 }
 
-func (g *Game) InitForPlayer(input io.Reader) *bufio.Scanner {
-	fmt.Fprintf(g.Output, WelcomeMessage)
-	scanner := bufio.NewScanner(input)
-	fmt.Fprintf(g.Output, "> ")
+// Elapsed is the game time so far, leaving out any time spent paused.
+func (g *Game) Elapsed() time.Duration {
+	now := g.Clock.Now()
+	if g.Paused {
+		now = g.PausedAt
+	}
+	return now.Sub(g.StartTime) - g.PausedFor
+}
+
+func (g *Game) Start() {
+	g.emit("game.welcome", nil)
+	g.Renderer.Prompt()
 	g.State = Playing
-	return scanner
+}
+
+func (g *Game) InitForPlayer(input io.Reader) *bufio.Scanner {
+	g.Start()
+	return bufio.NewScanner(input)
 }
 
 func (g *Game) ReadCommands(scanner *bufio.Scanner, commands chan string) {
@@ -372,49 +731,83 @@ func (g *Game) ReadCommands(scanner *bufio.Scanner, commands chan string) {
 	close(commands)
 }
 
+func (g *Game) end(o Outcome) {
+	g.Outcome = o
+	g.State = End
+	g.reportReactions()
+}
+
+func (g *Game) ExitCode() int {
+	switch g.Outcome {
+	case Won:
+		return ExitWin
+	case Lost:
+		return ExitLose
+	default:
+		return ExitQuit
+	}
+}
+
 func (g *Game) winCheck() {
-	if len(g.MoleFactory.MoleSet.Dead) == g.WinCondition {
-		fmt.Fprintf(g.Output, "Moles eliminated, YOU WIN!!!!\n")
-		g.State = End
+	if g.State != End && len(g.MoleFactory.MoleSet.Dead) == g.WinCondition {
+		g.emit("game.won", nil)
+		g.end(Won)
+	}
+}
+
+func (g *Game) timeCheck() {
+	if g.Config.TimeLimit > 0 && g.Elapsed() >= g.Config.TimeLimit {
+		g.emit("game.timeout", Fields{"limit": g.Config.TimeLimit})
+		g.end(Lost)
 	}
 }
 
 func (g *Game) handleWhack(hole string) {
 	hId, err := strconv.Atoi(hole)
-	fmt.Fprintf(g.Output, "SHLONK!\n")
 	if err != nil {
-		fmt.Fprintf(g.Output, "Hole ID not recognized, where are you aiming?!\n")
+		g.respond("whack.unknown_hole", Fields{"hole": hole})
 		return
 	}
 	h := g.HoleFactory.HoleSet.GetHole(hId)
 	if h == nil {
-		fmt.Fprintf(g.Output, "Hole ID not recognized, where are you aiming?!\n")
+		g.respond("whack.unknown_hole", Fields{"hole": hole})
 		return
 	}
-	hit, resp := h.TryWhack()
-	if hit {
-		g.winCheck()
-	}
-	fmt.Fprint(g.Output, resp)
+	g.swing(h)
 }
 
 func (g *Game) handleMoles() {
-	msg := g.MoleFactory.MoleSet.GetMoleStats()
-	fmt.Fprint(g.Output, msg)
+	ms := &g.MoleFactory.MoleSet
+	f := Fields{"alive": len(ms.Housed) + len(ms.Unhoused), "dead": len(ms.Dead), "goal": g.WinCondition}
+	if g.Config.Breeding.Overrun > 0 {
+		f["overrun"] = g.Config.Breeding.Overrun
+		g.respond("moles.stats_overrun", f)
+		return
+	}
+	g.respond("moles.stats", f)
 }
 func (g *Game) handleHoles() {
-	msg := g.HoleFactory.HoleSet.PrintHolesString()
-	fmt.Fprint(g.Output, msg)
+	var items []Fields
+	for _, ho := range g.HoleFactory.HoleSet.Index.Holes {
+		state := "open"
+		switch ho.State {
+		case Collapsed:
+			state = "collapsed"
+		case Blocked:
+			state = "blocked"
+		}
+		items = append(items, Fields{"id": ho.ID, "state": word(state)})
+	}
+	g.respondList("holes.list", nil, items)
 }
 
 func (g *Game) handleHelp() {
-	fmt.Fprintf(g.Output, HelpMessage)
+	g.respond("game.help", nil)
 }
 
 func (g *Game) handleQuit() {
-	fmt.Fprintf(g.Output, "GOODBYE QUITTER!\n")
-	g.State = End
-	//os.Exit(0)
+	g.respond("game.quit", nil)
+	g.end(Quit)
 }
 
 func (g *Game) ProcessPlayerInput(commands string) {
@@ -423,73 +816,499 @@ func (g *Game) ProcessPlayerInput(commands string) {
 	if len(parts) == 0 {
 		return
 	}
+	g.LastInput = g.Clock.Now()
+	g.Replay.add(g.Elapsed(), input)
+	if g.Paused && !pausedCommands[parts[0]] {
+		g.respond("pause.blocked", nil)
+		g.Renderer.Prompt()
+		return
+	}
+	if g.Config.Debug && debugCommands[parts[0]] {
+		g.ProcessDebugInput(parts)
+		g.Renderer.Prompt()
+		return
+	}
+	if parts[0] == "m" && g.Config.Versus > 0 {
+		g.ProcessMoleInput(strings.Join(parts[1:], " "))
+		g.Renderer.Prompt()
+		return
+	}
 
 	switch parts[0] {
 	case "whack":
 		if len(parts) > 1 {
 			g.handleWhack(parts[1])
 		} else {
-			fmt.Fprintf(g.Output, "Hole ID Not Specified\n")
+			g.respond("whack.no_hole", nil)
 		}
+	case "hammer":
+		g.handleHammer(parts[1:])
+	case "stats":
+		g.handleStats()
+		g.itemStats()
+	case "items":
+		g.handleItems()
+	case "trap", "bait":
+		g.handlePlace(parts[0], parts[1:])
+	case "smoke":
+		g.handleSmoke(parts[1:])
+	case "peek":
+		g.handlePeek(parts[1:])
+	case "map":
+		g.handleMap()
+	case "plug":
+		g.handlePlug(parts[1:])
+	case "unplug":
+		g.handleUnplug(parts[1:])
 	case "moles":
 		g.handleMoles()
 	case "holes":
 		g.handleHoles()
+	case "history":
+		g.handleHistory(parts[1:])
+	case "difficulty":
+		g.handleDifficulty()
+	case "achievements":
+		g.handleAchievements()
+	case "pause":
+		g.handlePause()
+	case "resume":
+		g.handleResume()
 	case "help":
 		g.handleHelp()
 	case "quit":
 		g.handleQuit()
 	default:
-		fmt.Fprintf(g.Output, "unknown commands\n")
+		g.respond("command.unknown", Fields{"command": parts[0]})
 	}
-	fmt.Fprintf(g.Output, "> ")
+	g.Renderer.Prompt()
 
 }
 
+// RunPlayLoop ticks the game and feeds it commands until it ends.  The
+// ticker is ignored while the game is paused and restarted on resume, so the
+// first tick after a pause comes a full tick later.  It is also restarted
+// when the difficulty controller changes the tick rate.
 func (g *Game) RunPlayLoop(commands chan string) {
-	tick := time.NewTicker(time.Second)
+	tick := time.NewTicker(g.Config.Tick)
 	defer tick.Stop()
 	for g.State != End {
+		ticks := tick.C
+		if g.Paused {
+			ticks = nil
+		}
 		select {
-		case <-tick.C:
-			g.ProcessMoleMoves(30)
+		case <-ticks:
+			g.ProcessTick()
+			g.Renderer.Prompt()
 		case cmd, ok := <-commands:
 			if !ok {
+				g.end(Quit)
 				return
 			}
+			paused, rate := g.Paused, g.Config.Tick
 			g.ProcessPlayerInput(cmd)
+			if (paused && !g.Paused) || rate != g.Config.Tick {
+				tick.Reset(g.Config.Tick)
+			}
 		}
 	}
 }
 
-func (g *Game) ProcessMoleMoves(entropy int) {
+// ProcessTick moves the game on by one tick.  Events raised during the tick
+// are held back and rendered together at the end of it, see flushEvents.
+func (g *Game) ProcessTick() {
+	if !g.stepping {
+		g.Replay.add(g.Elapsed(), "tick")
+	}
+	g.idleCheck()
+	if g.Paused || g.Frozen {
+		return
+	}
+	g.Ticks++
+	g.batching = true
+	g.ProcessTerrain()
+	g.ProcessMoleMoves(g.Config.Entropy)
+	g.ProcessBoardEvents()
+	g.BreedMoles()
+	g.winCheck()
+	g.timeCheck()
+	g.survivalCheck()
+	g.batching = false
+	g.flushEvents()
+}
 
-	for _, m := range g.MoleFactory.MoleSet.Unhoused {
-		m.Tunnel(&g.HoleFactory.HoleSet)
+// ProcessMoleMoves walks the moles in ID order, first letting the ones
+// underground dig on and then giving each housed mole its chance to move
+// or to pop up or down.
+func (g *Game) ProcessMoleMoves(entropy int) {
+	ms := &g.MoleFactory.MoleSet
+	for _, m := range ms.Index.Moles {
+		if _, ok := ms.Unhoused[m.ID]; !ok {
+			continue
+		}
+		if m.Controlled {
+			g.travel(m)
+			continue
+		}
+		g.dig(m)
 	}
 
-	for _, m := range g.MoleFactory.MoleSet.Housed {
-		if rand.Intn(100) < entropy {
-			fmt.Fprintf(g.Output, "mole %d vanished!\n", m.ID)
-			m.Tunnel(&g.HoleFactory.HoleSet)
+	for _, m := range ms.Index.Moles {
+		if _, ok := ms.Housed[m.ID]; !ok || m.Controlled {
+			continue
 		}
-		if rand.Intn(100) < entropy {
+		g.hideOverexposed(m)
+		if g.Rand.Intn(100) < entropy {
+			g.emit("mole.vanished", Fields{"mole": m.ID})
+			g.burrow(m)
+			if m.HoleOccupied == nil {
+				continue
+			}
+		}
+		if g.Rand.Intn(100) < entropy {
 			m.ToggleState()
 			if m.State == HidingAlive {
-				fmt.Fprintf(g.Output, "mole %d vanished!\n", m.ID)
+				g.emit("mole.vanished", Fields{"mole": m.ID})
 			} else {
-				fmt.Fprintf(g.Output, "mole %d appeared in hole %d!\n", m.ID, m.HoleOccupied.ID)
+				key := "mole.appeared"
+				if m.Armored {
+					key = "mole.appeared_armored"
+				}
+				if g.visible(m.HoleOccupied.ID) {
+					g.emit(key, Fields{"mole": m.ID, "hole": m.HoleOccupied.ID})
+				}
 			}
 		}
 	}
 }
 
 func main() {
-	rand.Seed(time.Now().UnixNano())
-	g := NewGame(os.Stdout)
-	g.Init(3, 3)
-	commands := make(chan string)
-	scanner := g.InitForPlayer(os.Stdin)
-	go g.ReadCommands(scanner, commands)
-	g.RunPlayLoop(commands)
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(args) > 0 && args[0] == "sim" {
+		return runSim(args[1:], stdout, stderr)
+	}
+	if len(args) > 0 && args[0] == "gym" {
+		return runGym(args[1:], stdin, stdout, stderr)
+	}
+	if len(args) > 0 && args[0] == "serve" {
+		return runServe(args[1:], stdout, stderr)
+	}
+	if len(args) > 0 && args[0] == "leaderboard" {
+		return runLeaderboard(args[1:], stdout, stderr)
+	}
+	if len(args) > 0 && args[0] == "lang" {
+		return runLang(args[1:], stdout, stderr)
+	}
+
+	fs := flag.NewFlagSet("wam", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	holes := fs.Int("holes", 3, "number of holes on the board")
+	moles := fs.Int("moles", 3, "number of moles to whack")
+	entropy := fs.Int("entropy", 30, "percent chance per tick that a mole moves or changes exposure")
+	tick := fs.Duration("tick", time.Second, "time between mole moves")
+	fog := fs.Bool("fog", false, "hide moles appearing unless the radar is looking at their hole")
+	peeks := fs.Int("peeks", 3, "radar charges (negative for unlimited)")
+	peekCooldown := fs.Duration("peek-cooldown", 5*time.Second, "time the radar needs between peeks")
+	breedRate := fs.Float64("breed-rate", 0, "chance per tick that each living mole has a pup")
+	breedCap := fs.Int("breed-cap", 0, "most moles that can be alive at once through breeding (0 for no cap)")
+	overrun := fs.Int("overrun", 0, "lose once more than this many moles are alive (0 to never lose this way)")
+	collapseChance := fs.Float64("collapse-chance", 0, "chance per tick that each hole caves in")
+	collapseTicks := fs.Int("collapse-ticks", 5, "ticks a caved in hole stays unusable")
+	openChance := fs.Float64("open-chance", 0, "chance per tick that a new hole opens")
+	maxHoles := fs.Int("max-holes", 0, "most holes the board can grow to (0 for no limit)")
+	plugs := fs.Int("plugs", 0, "plugs the player can use to block holes")
+	tunnels := fs.String("tunnels", "", "tunnel network between holes: grid, ring or random (default lets moles go anywhere)")
+	tunnelFile := fs.String("tunnel-file", "", "read the tunnel network from this file, one hole and its links per line")
+	edgeTicks := fs.Int("edge-ticks", 1, "ticks a mole spends underground for each tunnel it takes")
+	selection := fs.String("select", "first", "how moles pick a hole to come up in: first, uniform, lru, weighted or round-robin")
+	weights := fs.String("weights", "", "hole weights for weighted selection, as 1:3,4:0.5 (holes default to 1)")
+	scoreRules := fs.String("score-rules", "", "read the scoring rules from this JSON file")
+	adaptive := fs.Bool("adaptive", false, "adjust entropy, exposure time and tick rate to keep the player near a target hit rate")
+	targetHitRate := fs.Float64("target-hit-rate", DefaultAdaptiveRules().TargetHitRate, "hit rate adaptive difficulty aims for")
+	exposeTicks := fs.Int("expose-ticks", 0, "ticks a mole stays exposed before ducking back down (0 for no limit)")
+	versus := fs.Int("versus", 0, "hand this many moles to a second player, whose commands start with \"m \"")
+	idle := fs.Duration("idle", 0, "pause the game after this long without any input (0 to never pause)")
+	eventLimit := fs.Int("event-limit", 100, "most mole and hole events shown each tick, the rest are summed up (0 for no limit)")
+	armored := fs.Int("armored", 0, "number of moles wearing armor that only the heavy hammer gets through")
+	timeLimit := fs.Duration("time-limit", 0, "lose if the moles are still alive after this long (0 for no limit)")
+	seed := fs.Int64("seed", 0, "random seed (defaults to the time, or 1 when running a script)")
+	script := fs.String("script", "", "run timed commands from this file instead of stdin")
+	transcript := fs.String("transcript", "", "write the script transcript to this file instead of stdout")
+	output := fs.String("output", "text", "output mode: text, json or quiet")
+	lang := fs.String("lang", "en", "language to play in: "+strings.Join(Languages(), ", "))
+	colour := fs.String("color", "auto", "colour hole and mole states: auto (on a terminal, unless NO_COLOR is set), always or never")
+	theme := fs.String("theme", "", "change the colours, as exposed=bold+red,hiding=yellow")
+	a11y := fs.Bool("a11y", false, "accessibility mode: no colour, a bell and a fuller message when a mole comes up, a slower tick (except in the daily challenge) and a plain prompt")
+	mode := fs.String("mode", "classic", "game mode: classic, or survival where the moles keep breeding until they overrun the board")
+	player := fs.String("player", os.Getenv("USER"), "name to keep achievements under")
+	achievementsFile := fs.String("achievements", DefaultAchievementPath(), "file achievements are kept in (empty to not keep any), not used with -script")
+	achievementDefs := fs.String("achievement-defs", "", "read the achievements from this JSON file instead of the built in ones")
+	boardEventChance := fs.Float64("board-event-chance", 0, "chance per tick of a board wide event: an earthquake, flood, frenzy or fog")
+	boardEvents := fs.String("board-events", "", "read the board event schedule from this JSON file")
+	inventory := fs.String("items", "trap:1,bait:1,smoke:1", "items to start with, as trap:2,bait:1,smoke:1")
+	debug := fs.Bool("debug", false, "turn on the debug commands: spawn, expose, hide, move, freeze, step, dump and seed")
+	daily := fs.Bool("daily", false, "play today's challenge, the same board for everyone, with the settings fixed")
+	leaderboardFile := fs.String("leaderboard", DefaultLeaderboardPath(), "file daily results are kept in")
+	replays := fs.String("replays", DefaultReplayDir(), "directory daily replays are written to")
+	if err := fs.Parse(args); err != nil {
+		return ExitError
+	}
+	seedSet, tickSet := false, false
+	var locked []string
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			seedSet = true
+		}
+		if f.Name == "tick" {
+			tickSet = true
+		}
+		if !dailyFlags[f.Name] {
+			locked = append(locked, "-"+f.Name)
+		}
+	})
+	if *daily && len(locked) > 0 {
+		fmt.Fprintf(stderr, "the daily challenge has fixed settings, drop %s\n", strings.Join(locked, " "))
+		return ExitError
+	}
+
+	g := NewGame(stdout)
+	g.Config.Entropy = *entropy
+	g.Config.Tick = *tick
+	if *a11y && !tickSet {
+		g.Config.Tick = AccessibleTick
+	}
+	g.Config.TimeLimit = *timeLimit
+	g.Config.ArmoredMoles = *armored
+	g.Config.Fog = *fog
+	g.Config.Terrain = Terrain{
+		CollapseChance: *collapseChance,
+		CollapseTicks:  *collapseTicks,
+		OpenChance:     *openChance,
+		MaxHoles:       *maxHoles,
+		Plugs:          *plugs,
+	}
+	g.Config.Breeding = Breeding{Rate: *breedRate, Cap: *breedCap, Overrun: *overrun}
+	g.Config.EventLimit = *eventLimit
+	g.Config.IdleTimeout = *idle
+	g.Config.ExposeTicks = *exposeTicks
+	g.Config.Versus = *versus
+	g.Config.Debug = *debug
+	if err := g.Config.ApplyMode(*mode, *holes); err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return ExitError
+	}
+	if *adaptive {
+		if *targetHitRate <= 0 || *targetHitRate > 1 {
+			fmt.Fprintf(stderr, "target hit rate must be between 0 and 1\n")
+			return ExitError
+		}
+		g.Config.Adaptive = DefaultAdaptiveRules()
+		g.Config.Adaptive.TargetHitRate = *targetHitRate
+	}
+	g.Config.PeekCharges = *peeks
+	g.Config.PeekCooldown = *peekCooldown
+	if g.Config.Tick <= 0 {
+		fmt.Fprintf(stderr, "tick must be positive\n")
+		return ExitError
+	}
+	if *edgeTicks < 0 {
+		fmt.Fprintf(stderr, "edge ticks can't be negative\n")
+		return ExitError
+	}
+	g.Config.Tunnels = Tunnels{Topology: *tunnels, EdgeTicks: *edgeTicks}
+	if *tunnelFile != "" {
+		f, err := os.Open(*tunnelFile)
+		if err != nil {
+			fmt.Fprintf(stderr, "%v\n", err)
+			return ExitError
+		}
+		links, err := LoadTunnels(f, *holes)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", *tunnelFile, err)
+			return ExitError
+		}
+		g.Config.Tunnels.Topology = "file"
+		g.Config.Tunnels.Links = links
+	}
+	if g.Config.Tunnels.Topology != "" {
+		if _, err := BuildTunnelGraph(g.Config.Tunnels, 0, nil); err != nil {
+			fmt.Fprintf(stderr, "%v, pick one of %s\n", err, strings.Join(Topologies[:3], ", "))
+			return ExitError
+		}
+	}
+	if _, err := NewHoleSelector(*selection, nil); err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return ExitError
+	}
+	if *scoreRules != "" {
+		f, err := os.Open(*scoreRules)
+		if err != nil {
+			fmt.Fprintf(stderr, "%v\n", err)
+			return ExitError
+		}
+		g.Config.Scoring, err = LoadScoreRules(f, g.Config.Scoring)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", *scoreRules, err)
+			return ExitError
+		}
+	}
+	if *boardEventChance < 0 || *boardEventChance > 1 {
+		fmt.Fprintf(stderr, "board event chance must be between 0 and 1\n")
+		return ExitError
+	}
+	g.Config.BoardEvents.Chance = *boardEventChance
+	if *boardEvents != "" {
+		f, err := os.Open(*boardEvents)
+		if err != nil {
+			fmt.Fprintf(stderr, "%v\n", err)
+			return ExitError
+		}
+		g.Config.BoardEvents, err = LoadBoardEvents(f, g.Config.BoardEvents)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", *boardEvents, err)
+			return ExitError
+		}
+	}
+	holeWeights, err := ParseWeights(*weights)
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return ExitError
+	}
+	g.Config.Items, err = ParseItems(*inventory)
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return ExitError
+	}
+	g.Config.Selection = *selection
+	g.Config.HoleWeights = holeWeights
+	renderer, err := NewRenderer(*output, stdout)
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return ExitError
+	}
+	catalog, err := NewCatalog(*lang)
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return ExitError
+	}
+	colours, err := ParseTheme(DefaultTheme(), *theme)
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return ExitError
+	}
+	if *a11y {
+		*colour = "never"
+	}
+	// textOptions sets up a text renderer writing to out with the language
+	// and look asked for.
+	textOptions := func(r Renderer, out io.Writer) error {
+		t, ok := r.(*TextRenderer)
+		if !ok {
+			return nil
+		}
+		t.Catalog = catalog
+		t.Accessible = *a11y
+		on, err := useColour(*colour, out)
+		if on {
+			t.Theme = colours
+		}
+		return err
+	}
+	if err := textOptions(renderer, stdout); err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return ExitError
+	}
+	g.Renderer = renderer
+
+	defs := DefaultAchievements()
+	if *achievementDefs != "" {
+		f, err := os.Open(*achievementDefs)
+		if err != nil {
+			fmt.Fprintf(stderr, "%v\n", err)
+			return ExitError
+		}
+		defs, err = LoadAchievements(f)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(stderr, "%s: %v\n", *achievementDefs, err)
+			return ExitError
+		}
+	}
+
+	if *daily {
+		return runDaily(g, NewDailyChallenge(time.Now()), dailyOptions{
+			player:       *player,
+			leaderboard:  *leaderboardFile,
+			replays:      *replays,
+			achievements: *achievementsFile,
+			defs:         defs,
+		}, stdin, stderr)
+	}
+
+	if *script == "" {
+		if seedSet {
+			g.SetSeed(*seed)
+		}
+		// Debug commands make achievements too easy to be worth keeping.
+		var store *AchievementStore
+		if *achievementsFile != "" && !*debug {
+			store, err = LoadAchievementStore(*achievementsFile)
+			if err != nil {
+				fmt.Fprintf(stderr, "%v\n", err)
+				return ExitError
+			}
+			g.Achievements = NewAchievements(defs, store.Player(*player))
+		}
+		g.Init(*holes, *moles)
+		commands := make(chan string)
+		scanner := g.InitForPlayer(stdin)
+		go g.ReadCommands(scanner, commands)
+		g.RunPlayLoop(commands)
+		if store != nil {
+			if err := store.Save(*achievementsFile); err != nil {
+				fmt.Fprintf(stderr, "saving achievements: %v\n", err)
+			}
+		}
+		return g.ExitCode()
+	}
+
+	f, err := os.Open(*script)
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return ExitError
+	}
+	cmds, err := ParseScript(f)
+	f.Close()
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", *script, err)
+		return ExitError
+	}
+	if *transcript != "" {
+		out, err := os.Create(*transcript)
+		if err != nil {
+			fmt.Fprintf(stderr, "%v\n", err)
+			return ExitError
+		}
+		defer out.Close()
+		g.Output = out
+		g.Renderer, _ = NewRenderer(*output, out)
+		textOptions(g.Renderer, out)
+	}
+	if !seedSet {
+		*seed = 1
+	}
+	g.SetSeed(*seed)
+	clock := NewManualClock(time.Unix(0, 0).UTC())
+	g.Clock = clock
+	g.Init(*holes, *moles)
+	g.Start()
+	g.RunScript(cmds, clock)
+	return g.ExitCode()
 }
@@ -6,8 +6,23 @@ import (
 	"github.com/stretchr/testify/require"
 	"strings"
 	"testing"
+	"time"
 )
 
+// newTestGame lays out a game on a manual clock that writes to a buffer.
+// setup, if given, changes the rules first.
+func newTestGame(holes, moles int, setup func(g *Game)) (*Game, *ManualClock, *bytes.Buffer) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	clock := NewManualClock(time.Unix(0, 0))
+	g.Clock = clock
+	if setup != nil {
+		setup(g)
+	}
+	g.Init(holes, moles)
+	return g, clock, &buf
+}
+
 func TestHole(t *testing.T) {
 	f := NewHoleFactory()
 	h, _ := f.NewHole()
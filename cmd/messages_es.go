@@ -0,0 +1,305 @@
+package main
+
+const WelcomeMessageES = `
+|||=======TOPOS TOPOS TOPOS TOPOS=======|||
+Bienvenido a un maravilloso juego de topos. Es muy sencillo:
+Hay agujeros que se pueden golpear y hay topos a los que hay que golpear.
+¡Golpea a todos los topos! ¡¡¡¡YA!!!!
+
+`
+
+const HelpMessageES = `
+|||=======AYUDA AYUDA AYUDA AYUDA=======|||
+De eso se trata el juego, de golpear a todos los topos:
+
+Órdenes:
+- whack [#]
+	Intenta golpear a un topo en el agujero #.  Si hay un topo y está fuera, el golpe acierta y el topo sale del juego.
+- hammer [nombre]
+	Cambia de martillo, o lista tus martillos si no das un nombre.  El mallet golpea un agujero, el wide también alcanza los agujeros vecinos pero puede rebotar en ellos, y el lento heavy es el único que atraviesa la armadura.  Cada martillo necesita tiempo para enfriarse entre golpes.
+- stats
+	Muestra tu récord de golpes y tu puntuación, en total y para cada martillo.  Los aciertos seguidos forman una racha que multiplica los puntos de cada acierto, una racha bastante larga desata un frenesí con puntos extra y cualquier golpe que no da en nada rompe la racha.
+- peek [# | #-#]
+	Pasa el radar por todos los agujeros, por el agujero # y sus vecinos, o por un rango de agujeros.  Muestra qué agujeros esconden un topo y qué topos están fuera, y mantiene esa parte del tablero a la vista hasta el siguiente tic.  El radar tiene cargas limitadas y necesita calentarse entre usos.
+- plug [#]
+	Tapa el agujero # para que ningún topo lo use.  Solo se pueden tapar agujeros vacíos y tienes pocos tapones.
+- unplug [#]
+	Quita el tapón del agujero # y guárdalo en el bolsillo.
+- items
+	Lista tus objetos y dónde los has puesto.
+- trap [#]
+	Pon una trampa en el agujero vacío #.  El siguiente topo que salga ahí queda atrapado.
+- bait [#]
+	Pon cebo en el agujero vacío #.  El siguiente topo que cave un túnel irá a por él.
+- smoke [# | #-#]
+	Lanza una bomba de humo sobre el agujero # y sus vecinos, o sobre un rango de agujeros.  Todos los topos escondidos ahí salen.
+- map
+	Dibuja los túneles entre los agujeros.  Los topos solo viajan por los túneles, así que fíjate en los agujeros por los que tienen que pasar.
+- history [#]
+	Repasa todo lo que ha hecho el topo # desde que nació.
+- difficulty
+	Muestra lo animados que están los topos.  Con la dificultad adaptativa también lista cada cambio hecho para mantenerte cerca del porcentaje de aciertos buscado.
+- achievements
+	Muestra los logros que se mantienen de partida en partida, cuáles has desbloqueado y cuánto te falta para los demás.
+- pause
+	Pausa el juego.  Los topos se quedan quietos y el reloj se para hasta que reanudes.  El juego también se pausa solo si pasas demasiado tiempo sin escribir.
+- resume
+	Sigue después de una pausa.
+- moles
+	Revisa los topos.  Dice cuántos topos quedan.
+- holes
+	Revisa los agujeros.  Da información sobre todos los sitios que se pueden golpear.
+- quit
+	Sale del juego.
+
+Modo versus:
+Cuando un segundo jugador controla a los topos escribe sus órdenes en la misma terminal, empezando cada una con "m".  Las órdenes que llevan un topo # usan el topo vivo de número más bajo si no se indica.
+- m moles
+	Lista tus topos y dónde están.
+- m hide [topo #]
+	Vuelve a esconderte en el agujero.
+- m expose [topo #]
+	Asómate fuera del agujero.  Es descarado, pero te pueden golpear.
+- m tunnel [agujero #] [topo #]
+	Muévete a otro agujero vacío.  En una red de túneles los agujeros tienen que estar unidos y el viaje lleva un rato.
+- m peek-hammer
+	Mira qué martillo tiene el que golpea, si está listo y dónde golpeó por última vez.
+- help
+	Estás aquí.  Escríbelo otra vez y volverás a estar aquí.
+
+Órdenes de depuración:
+Solo con -debug, y nunca en una partida clasificada.
+- spawn [n]
+	Añade n topos nuevos, en agujeros si hay sitio.
+- expose [topo #] / hide [topo #]
+	Saca un topo o escóndelo.
+- move [topo #] [agujero #]
+	Pon un topo directamente en un agujero vacío.
+- freeze
+	Detiene o reanuda los tics.  El reloj sigue corriendo.
+- step
+	Ejecuta un tic, aunque esté congelado.
+- dump
+	Lista todos los agujeros y topos con los conjuntos en los que están.
+- seed [n]
+	Muestra la semilla aleatoria, o vuelve a sembrar con n.
+`
+
+var messagesES = map[string]string{
+	"game.welcome":           WelcomeMessageES,
+	"game.help":              HelpMessageES,
+	"game.won":               "Topos eliminados, ¡¡¡¡HAS GANADO!!!!\n",
+	"game.timeout":           "¡Se acabó el tiempo, ganan los topos! ¡HAS PERDIDO!\n",
+	"game.quit":              "¡ADIÓS, RENDIDO!\n",
+	"script.command":         "@{at} {line}\n",
+	"script.exhausted":       "\nEl guion terminó con topos aún vivos, ¡HAS PERDIDO!\n",
+	"command.unknown":        "orden desconocida\n",
+	"whack.no_hole":          "No has indicado el agujero\n",
+	"whack.unknown_hole":     "¡PLONK!\nNo existe ese agujero, ¡¿a dónde apuntas?!\n",
+	"whack.hit":              "¡PLONK!\n¡aplastado para siempre!\n",
+	"whack.miss":             "¡PLONK!\n¡fallaste y ahora se está riendo!\n",
+	"whack.whiff":            "¡PLONK!\n¡al aire, aquí no hay topos!\n",
+	"whack.deflected":        "¡PLONK!\n¡CLANG! la armadura aguantó, ¡busca un martillo más pesado!\n",
+	"whack.sweep":            "¡FIUUU!\n",
+	"whack.sweep.item":       "  agujero {hole}: {result}\n",
+	"hammer.cooling":         "El {hammer} aún se está enfriando, ¡espera {wait}!\n",
+	"hammer.list":            "Martillos (tienes el {current}):\n",
+	"hammer.list.item":       "  {hammer}: enfriamiento {cooldown}, {status}\n",
+	"hammer.switched":        "Coges el {hammer}.\n",
+	"hammer.unknown":         "¡No tienes ningún martillo llamado {hammer}!\n",
+	"peek":                   "Barrido de radar, quedan {charges} usos:\n",
+	"peek.item":              "  agujero {hole}: {status}\n",
+	"peek.free":              "Barrido de radar:\n",
+	"peek.free.item":         "  agujero {hole}: {status}\n",
+	"peek.empty":             "¡El radar no tiene cargas!\n",
+	"peek.cooling":           "El radar aún se está calentando, ¡espera {wait}!\n",
+	"peek.bad_region":        "No se puede apuntar el radar a {region}, prueba un agujero como 4 o un rango como 2-6.\n",
+	"stats":                  "Golpes: {whacks}  Aciertos: {hits}  Fallos: {misses}  Al aire: {whiffs}  Precisión: {accuracy:%.0f}%\nPuntos: {score}  Racha más larga: {longest_streak}  Mejor multiplicador: x{max_multiplier:%.1f}  Frenesís: {frenzies}\n",
+	"stats.item":             "  {hammer}: {whacks} golpes, {hits} aciertos, {misses} fallos ({deflected} contra armadura), {whiffs} al aire\n",
+	"moles.stats":            "Vivos: {alive}\nMuertos: {dead}\nPara ganar: {goal} muertos\n",
+	"moles.stats_overrun":    "Vivos: {alive}\nMuertos: {dead}\nPara ganar: {goal} muertos\nInvasión: más de {overrun} vivos\n",
+	"mole.born":              "el topo {parent} ha tenido una cría, ¡el topo {mole} anda suelto!\n",
+	"game.overrun":           "¡{alive} topos! Han invadido el jardín, ¡HAS PERDIDO!\n",
+	"holes.list":             "",
+	"holes.list.item":        "agujero: {id} ({state})\n",
+	"hole.collapsed":         "¡el agujero {hole} se ha hundido! no se podrá usar durante {ticks} tics.\n",
+	"hole.reopened":          "el agujero {hole} se ha vuelto a excavar.\n",
+	"hole.opened":            "¡se ha abierto un agujero nuevo, el {hole}!\n",
+	"plug.placed":            "Tapas el agujero {hole}. Quedan {plugs} tapones.\n",
+	"plug.removed":           "Quitas el tapón del agujero {hole}. Quedan {plugs} tapones.\n",
+	"plug.none":              "¡No te quedan tapones!\n",
+	"plug.bad_hole":          "No se puede tapar, elige un agujero abierto y vacío.\n",
+	"plug.not_plugged":       "Ese agujero no tiene tapón.\n",
+	"plug.unknown_hole":      "¡No existe el agujero {hole}!\n",
+	"plug.no_hole":           "¿Qué agujero? Indica un número de agujero.\n",
+	"map.open":               "No hay túneles, ¡los topos pueden salir por cualquier agujero!\n",
+	"map":                    "Túneles ({topology}):\n",
+	"map.item":               "  agujero {hole} ({state}) -> {links}\n",
+	"map.grid":               "Túneles ({topology}):\n{drawing}",
+	"events.throttled":       "...y pasaron {dropped} cosas más que no llegaste a ver.\n",
+	"pause.paused":           "En pausa a los {elapsed}. Escribe resume para seguir.\n",
+	"pause.idle":             "No has escrito nada en {idle}, así que el juego está en pausa. Escribe resume para seguir.\n",
+	"pause.resumed":          "¡A golpear otra vez!\n",
+	"pause.already":          "El juego ya está en pausa.\n",
+	"pause.not_paused":       "El juego no está en pausa.\n",
+	"pause.blocked":          "El juego está en pausa, escribe resume primero.\n",
+	"history":                "Topo {mole}:\n",
+	"history.item":           "  {at:%8v}  {event} ({where})\n",
+	"history.truncated":      "Topo {mole} (no se guardan {dropped} sucesos antiguos después de nacer):\n",
+	"history.truncated.item": "  {at:%8v}  {event} ({where})\n",
+	"history.no_mole":        "¿Qué topo? Indica un número de topo.\n",
+	"history.unknown_mole":   "¡No existe el topo {mole}!\n",
+	"reactions":              "Tiempos de reacción en {count} aciertos: media {mean}, el más rápido {fastest}, el más lento {slowest}\n",
+	"reactions.item":         "  {range:%-12s} {bar} {count}\n",
+	"combo.streak":           "¡{streak} seguidos! x{multiplier:%.1f} por {points} puntos, {score} en total.\n",
+	"combo.broken":           "¡Racha de {streak} rota!\n",
+	"combo.frenzy":           "¡FRENESÍ! {streak} seguidos, ¡{bonus} puntos extra!\n",
+	"difficulty.changed":     "Dificultad {direction} (aciertos {hit_rate:%.0f}%): entropía {entropy}, los topos se quedan fuera {expose_ticks} tics, tic {tick}\n",
+	"difficulty":             "Entropía {entropy}, los topos se quedan fuera {expose_ticks} tics, tic {tick}, buscando un {target:%.0f}% de aciertos\n",
+	"difficulty.item":        "  @{at} {direction}: aciertos {hit_rate:%.0f}%, reacción {reaction} -> entropía {entropy}, {expose_ticks} tics fuera, tic {tick}\n",
+	"difficulty.fixed":       "Entropía {entropy}, tic {tick}, la dificultad adaptativa está desactivada.\n",
+	"versus.moles":           "[topos] Tus topos:\n",
+	"versus.moles.item":      "  topo {mole}: {status}\n",
+	"versus.none_left":       "[topos] ¡Han golpeado a todos tus topos!\n",
+	"versus.no_mole":         "[topos] No tienes ningún topo {mole} que mover.\n",
+	"versus.not_housed":      "[topos] el topo {mole} está bajo tierra, primero ve por un túnel a un agujero.\n",
+	"versus.already":         "[topos] el topo {mole} ya está {state}.\n",
+	"versus.hidden":          "[topos] el topo {mole} se agacha en el agujero {hole}.\n",
+	"versus.exposed":         "[topos] ¡el topo {mole} se asoma en el agujero {hole}!\n",
+	"versus.no_hole":         "[topos] ¿Qué agujero? Indica un número de agujero.\n",
+	"versus.bad_hole":        "[topos] ¡No existe el agujero {hole}!\n",
+	"versus.blocked":         "[topos] el agujero {hole} no está libre, el topo {mole} se queda donde está.\n",
+	"versus.no_tunnel":       "[topos] No hay túnel del agujero {from} al agujero {hole}.\n",
+	"versus.tunneling":       "[topos] el topo {mole} baja por el túnel hacia el agujero {hole}, a {ticks} tics.\n",
+	"versus.tunneled":        "[topos] el topo {mole} pasa por el túnel al agujero {hole}.\n",
+	"versus.travelling":      "[topos] el topo {mole} todavía va por el túnel, espera a que salga.\n",
+	"versus.hammer":          "[topos] El que golpea tiene el {hammer} ({status}), último golpe en {last}.\n",
+	"versus.unknown":         "[topos] Orden desconocida {command}, prueba hide, expose, tunnel, peek-hammer o moles.\n",
+	"achievement.unlocked":   "*** Logro desbloqueado: {name}, ¡{description}! ***\n",
+	"achievements":           "{unlocked} de {total} logros desbloqueados:\n",
+	"achievements.item":      "  {name}: {description} ({status})\n",
+	"achievements.off":       "En esta partida no se guardan logros.\n",
+
+	// Los logros incluidos en el juego.
+	"achievement.sharpshooter.name":        "Francotirador",
+	"achievement.sharpshooter.description": "Limpia un tablero con un 100% de precisión",
+	"achievement.hat_trick.name":           "Triplete",
+	"achievement.hat_trick.description":    "Golpea 3 topos en menos de 2 segundos",
+	"achievement.clean_sweep.name":         "Barrida limpia",
+	"achievement.clean_sweep.description":  "Gana sin fallar ni un golpe al aire",
+	"achievement.survivor.name":            "Superviviente",
+	"achievement.survivor.description":     "Sobrevive 10 minutos en modo supervivencia",
+	"achievement.exterminator.name":        "Exterminador",
+	"achievement.exterminator.description": "Golpea 100 topos",
+
+	"survival.minute":        "¡Has sobrevivido {minutes} minutos!\n",
+	"daily.start":            "Desafío diario del {date}: modo {mode}, {holes} agujeros, {moles} topos ({armored} con armadura), entropía {entropy}.\n",
+	"daily.practice":         "{player} ya ha jugado hoy, este intento no puntúa.\n",
+	"daily.leaderboard":      "Clasificación del {date}:\n",
+	"daily.leaderboard.item": "  {rank}. {player}: {outcome}, {score} puntos en {elapsed}\n",
+	"debug.spawned":          "[debug] {count} topos creados: {moles}\n",
+	"debug.mole":             "[debug] el topo {mole} está {status}\n",
+	"debug.no_mole":          "[debug] no hay ningún topo vivo {mole}\n",
+	"debug.not_housed":       "[debug] el topo {mole} no está en un agujero\n",
+	"debug.bad_hole":         "[debug] el agujero {hole} no es un agujero vacío\n",
+	"debug.bad_count":        "[debug] no se pueden crear {count} topos\n",
+	"debug.freeze":           "[debug] congelado: {frozen}\n",
+	"debug.stepped":          "[debug] avanzado hasta el tic {tick}\n",
+	"debug.holes":            "[debug] {count} agujeros:\n",
+	"debug.holes.item":       "  agujero {hole}: {state}, topo {mole}, en {sets}\n",
+	"debug.moles":            "[debug] {count} topos, {win} para ganar, tic {tick}, congelado: {frozen}\n",
+	"debug.moles.item":       "  topo {mole}: {status}, en {sets}{flags}\n",
+	"debug.seed":             "[debug] semilla {seed}\n",
+	"debug.bad_seed":         "[debug] semilla no válida {seed}\n",
+	"daily.debug":            "Las órdenes de depuración están activadas, este intento no puntúa.\n",
+	"daily.replay":           "Repetición guardada en {path}\n",
+	"items":                  "Objetos:\n",
+	"items.item":             "  {item}: quedan {left}{placed}\n",
+	"items.none":             "En esta partida no tienes objetos.\n",
+	"item.none":              "¡No te queda {item}!\n",
+	"item.bad_hole":          "No se puede poner {item} ahí, elige un agujero abierto y vacío sin nada dentro.\n",
+	"item.no_hole":           "¿Dónde pones {item}? Indica un número de agujero.\n",
+	"item.unknown_hole":      "¡No existe el agujero {hole} para poner {item}!\n",
+	"item.no_region":         "¿Dónde echas el humo? Indica un agujero o un rango como 2-5.\n",
+	"item.bad_region":        "No se puede ahumar {region}, indica un agujero o un rango como 2-5.\n",
+	"item.placed":            "Pones {item} en el agujero {hole}. Quedan {left}.\n",
+	"item.smoked":            "¡PUF! {moles} topos sacados con humo. Quedan {left} bombas de humo.\n",
+	"item.trapped":           "¡ZAS! ¡el topo {mole} ha caído en la trampa del agujero {hole}!\n",
+	"item.lured":             "el topo {mole} fue a por el cebo del agujero {hole}.\n",
+	"stats.items":            "Trampas: {traps} puestas, {caught} atrapados  Cebo: {baits} puesto, {lured} atraídos  Humo: {smokes} usado, {smoked} sacados\n",
+	"board.earthquake":       "¡TERREMOTO! ¡La tierra tiembla y {moles} topos corren a buscar otros agujeros!\n",
+	"board.flood":            "¡INUNDACIÓN! ¡La fila {row} está bajo el agua durante {ticks} tics!\n",
+	"board.frenzy":           "¡FRENESÍ DE TOPOS! ¡{moles} topos se asoman a la vez!\n",
+	"board.fog":              "Llega la niebla, no verás salir a los topos durante {ticks} tics.\n",
+	"board.fog_lifted":       "La niebla se disipa.\n",
+	"net.welcome":            "Eres {player}.  Escribe \"name <nuevo nombre>\" para cambiarlo y \"scores\" para ver cómo le va a cada uno.\n",
+	"net.joined":             "{player} se ha unido a la partida.\n",
+	"net.left":               "{player} ha dejado la partida.\n",
+	"net.renamed":            "{old} ahora se llama {player}.\n",
+	"net.name_taken":         "Ya hay alguien que se llama {player}.\n",
+	"net.no_name":            "¿Qué nombre quieres?\n",
+	"net.kill":               "¡{player} golpeó al topo {mole} en el agujero {hole}!\n",
+	"net.beaten":             "Demasiado lento, {player} llegó antes al agujero {hole}.\n",
+	"net.scores":             "Puntuaciones:\n",
+	"net.scores.item":        "  {player}: {score} puntos, {kills} topos, {hits}/{whacks} aciertos ({accuracy:%.0f}%)\n",
+	"net.mole_player":        "{player} juega con los topos.\n",
+	"net.mole_taken":         "{player} ya juega con los topos.\n",
+	"net.mole_only":          "Juegas con los topos, deja los golpes y las trampas a los demás.\n",
+	"net.not_mole_player":    "No estás jugando con los topos.\n",
+	"net.mole_released":      "{player} deja de jugar con los topos.\n",
+	"net.no_pause":           "No se puede pausar la partida cuando juegan otras personas.\n",
+	"net.bye":                "¡Adiós, {player}!\n",
+	"net.spectators":         "{count} mirando.\n",
+	"spectate.welcome":       "Estás mirando, {delay} por detrás de la partida.  Jugadores: {players}\n",
+	"spectate.read_only":     "Los espectadores no pueden jugar, solo mirar.\n",
+	"spectate.board":         "Tablero:\n",
+	"spectate.board.item":    "  agujero {hole}: {status}\n",
+	"mole.vanished":          "¡el topo {mole} ha desaparecido!\n",
+	"mole.appeared":          "¡el topo {mole} ha salido en el agujero {hole}!\n",
+	"mole.appeared_armored":  "¡el topo con armadura {mole} ha salido en el agujero {hole}!\n",
+	"word.bonked":            "aplastado",
+	"word.missed":            "fallado",
+	"word.clang":             "clang",
+	"word.whiff":             "al aire",
+	"word.glanced":           "rebotado",
+	"word.ready":             "listo",
+	"word.cooling":           "enfriándose {wait}",
+	"word.cooling_for":       "enfriándose durante {wait}",
+	"word.nowhere_yet":       "ningún sitio todavía",
+	"word.hole":              "agujero {hole}",
+	"word.open":              "abierto",
+	"word.collapsed":         "hundido",
+	"word.blocked":           "tapado",
+	"word.empty":             "vacío",
+	"word.exposed":           "fuera",
+	"word.hiding":            "escondido",
+	"word.whacked":           "golpeado",
+	"word.underground":       "bajo tierra",
+	"word.exposed_in":        "fuera en el agujero {hole}",
+	"word.hiding_in":         "escondido en el agujero {hole}",
+	"word.mole_status":       "topo {mole} {status}",
+	"word.spawned":           "nacido",
+	"word.housed":            "en un agujero",
+	"word.hidden":            "escondido",
+	"word.left":              "salido",
+	"word.trapped":           "atrapado",
+	"word.died":              "muerto",
+	"word.harder":            "más difícil",
+	"word.easier":            "más fácil",
+	"word.hold":              "estable",
+	"word.won":               "ganado",
+	"word.lost":              "perdido",
+	"word.quit":              "abandonado",
+	"word.undecided":         "sin decidir",
+	"word.classic":           "clásico",
+	"word.survival":          "supervivencia",
+	"word.locked":            "bloqueado",
+	"word.unlocked":          "desbloqueado el {date}",
+	"word.set_in":            ", puesto en el agujero {holes}",
+
+	"prompt":                        "> ",
+	"prompt.accessible":             "Orden: ",
+	"mole.appeared.verbose":         "¡Topo fuera! el topo {mole} ha salido del agujero {hole}, golpea el {hole} ya.\n",
+	"mole.appeared_armored.verbose": "¡Topo con armadura fuera! el topo {mole} ha salido del agujero {hole}, hace falta el martillo heavy.\n",
+	"board.frenzy.verbose":          "¡Frenesí de topos! {moles} topos están fuera a la vez, revisa los agujeros.\n",
+}
@@ -0,0 +1,55 @@
+package main
+
+import "time"
+
+// Pause freezes the game.  No ticks run while paused and the time spent
+// paused is left out of Elapsed, so cooldowns, the time limit and the
+// times in records all carry on where they left off.
+func (g *Game) Pause() {
+	if g.Paused {
+		return
+	}
+	g.Paused = true
+	g.PausedAt = g.Clock.Now()
+}
+
+func (g *Game) Resume() {
+	if !g.Paused {
+		return
+	}
+	g.PausedFor += g.Clock.Now().Sub(g.PausedAt)
+	g.Paused = false
+	g.LastInput = g.Clock.Now()
+}
+
+// idleCheck pauses the game once the player has gone Config.IdleTimeout
+// without typing anything.
+func (g *Game) idleCheck() {
+	idle := g.Config.IdleTimeout
+	if idle <= 0 || g.Paused || g.Clock.Now().Sub(g.LastInput) < idle {
+		return
+	}
+	g.Pause()
+	g.emit("pause.idle", Fields{"idle": idle})
+}
+
+// pausedCommands are the commands that still work while the game is paused.
+var pausedCommands = map[string]bool{"resume": true, "pause": true, "help": true, "quit": true, "stats": true, "moles": true, "history": true, "difficulty": true, "achievements": true}
+
+func (g *Game) handlePause() {
+	if g.Paused {
+		g.respond("pause.already", nil)
+		return
+	}
+	g.Pause()
+	g.respond("pause.paused", Fields{"elapsed": g.Elapsed().Round(100 * time.Millisecond)})
+}
+
+func (g *Game) handleResume() {
+	if !g.Paused {
+		g.respond("pause.not_paused", nil)
+		return
+	}
+	g.Resume()
+	g.respond("pause.resumed", nil)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPauseFreezesGame(t *testing.T) {
+	g, clock, buf := newTestGame(4, 2, lively)
+	clock.Advance(2 * time.Second)
+	g.ProcessPlayerInput("pause")
+	assert.True(t, g.Paused)
+	assert.Contains(t, buf.String(), "Paused at 2s.")
+
+	m := g.MoleFactory.MoleSet.Housed[1]
+	before := *m
+	clock.Advance(time.Minute)
+	g.ProcessTick()
+	assert.Equal(t, 0, g.Ticks)
+	assert.Equal(t, before, *m)
+	assert.Equal(t, 2*time.Second, g.Elapsed())
+
+	buf.Reset()
+	g.ProcessPlayerInput("whack 1")
+	assert.Contains(t, buf.String(), "The game is paused, type resume first.")
+	assert.Zero(t, g.Stats.Whacks)
+	g.ProcessPlayerInput("pause")
+	assert.Contains(t, buf.String(), "already paused")
+
+	g.ProcessPlayerInput("resume")
+	assert.False(t, g.Paused)
+	assert.Equal(t, 2*time.Second, g.Elapsed())
+	clock.Advance(time.Second)
+	assert.Equal(t, 3*time.Second, g.Elapsed())
+	g.ProcessTick()
+	assert.Equal(t, 1, g.Ticks)
+
+	buf.Reset()
+	g.ProcessPlayerInput("resume")
+	assert.Contains(t, buf.String(), "The game isn't paused.")
+}
+
+func TestPauseKeepsTimeLimit(t *testing.T) {
+	g, clock, _ := newTestGame(4, 2, lively)
+	g.Config.TimeLimit = 5 * time.Second
+	clock.Advance(4 * time.Second)
+	g.Pause()
+	clock.Advance(10 * time.Second)
+	g.Resume()
+	g.ProcessTick()
+	assert.NotEqual(t, End, g.State)
+	clock.Advance(time.Second)
+	g.ProcessTick()
+	assert.Equal(t, Lost, g.Outcome)
+}
+
+func TestIdlePause(t *testing.T) {
+	g, clock, buf := newTestGame(4, 2, lively)
+	g.Config.IdleTimeout = 3 * time.Second
+	clock.Advance(2 * time.Second)
+	g.ProcessTick()
+	g.ProcessPlayerInput("moles")
+	clock.Advance(2 * time.Second)
+	g.ProcessTick()
+	assert.False(t, g.Paused)
+	clock.Advance(time.Second)
+	g.ProcessTick()
+	assert.True(t, g.Paused)
+	assert.Equal(t, 2, g.Ticks)
+	assert.Contains(t, buf.String(), "Nothing from you in 3s, so the game is paused.")
+
+	g.ProcessPlayerInput("resume")
+	g.ProcessTick()
+	assert.Equal(t, 3, g.Ticks)
+}
+
+func TestScriptPause(t *testing.T) {
+	g, clock, buf := newTestGame(4, 2, lively)
+	g.Config.Entropy = 0
+	g.Renderer = &JSONRenderer{Out: buf}
+	cmds, err := ParseScript(strings.NewReader("@1.5s pause\n@10s resume\n@12s moles\n"))
+	require.NoError(t, err)
+	g.RunScript(cmds, clock)
+	// One tick before the pause, then ticks at 10.5s and 11.5s of wall time.
+	assert.Equal(t, 3, g.Ticks)
+	assert.Equal(t, 3500*time.Millisecond, g.Elapsed())
+	assert.Contains(t, buf.String(), `"key":"pause.resumed","tick":1,"at":1.5`)
+}
+
+func lively(g *Game) {
+	g.Config.Entropy = 100
+}
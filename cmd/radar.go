@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Radar tracks the player's peeks.  A peek shows every hole in its region
+// right away and keeps that region in view until the end of the next tick,
+// which is what lets exposure messages through the fog.
+type Radar struct {
+	Charges     int
+	ReadyAt     time.Duration
+	RevealUntil int
+	Region      map[int]bool
+}
+
+func NewRadar(charges int) *Radar {
+	return &Radar{Charges: charges, RevealUntil: -1}
+}
+
+func (r *Radar) Reveals(tick int, hole int) bool {
+	if tick > r.RevealUntil {
+		return false
+	}
+	return r.Region == nil || r.Region[hole]
+}
+
+// visible reports whether the player can currently see what happens in a
+// hole.  Without fog, set for the game or rolling in as a board event,
+// everything is visible.
+func (g *Game) visible(hole int) bool {
+	fogged := g.Config.Fog || g.Ticks < g.FogUntil
+	return !fogged || g.Radar.Reveals(g.Ticks, hole)
+}
+
+// parseRegion reads a peek region: nothing for the whole board, "N" for a
+// hole and its neighbours or "N-M" for a range of holes.  A range is cut
+// down to the holes on the board, so a huge one costs no more than the
+// whole board, and a region with no hole on the board is an error.
+func (g *Game) parseRegion(args []string) (map[int]bool, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+	from, to, isRange := strings.Cut(args[0], "-")
+	lo, err := strconv.Atoi(from)
+	if err != nil {
+		return nil, fmt.Errorf("bad region %q", args[0])
+	}
+	region := map[int]bool{lo: true}
+	if !isRange {
+		if lo < 1 || lo > g.HoleFactory.HoleId {
+			return nil, fmt.Errorf("region %q is off the board", args[0])
+		}
+		for _, id := range g.Neighbours(lo) {
+			region[id] = true
+		}
+		return region, nil
+	}
+	hi, err := strconv.Atoi(to)
+	if err != nil || hi < lo {
+		return nil, fmt.Errorf("bad region %q", args[0])
+	}
+	lo, hi = max(lo, 1), min(hi, g.HoleFactory.HoleId)
+	if hi < lo {
+		return nil, fmt.Errorf("region %q is off the board", args[0])
+	}
+	region = make(map[int]bool, hi-lo+1)
+	for id := lo; id <= hi; id++ {
+		region[id] = true
+	}
+	return region, nil
+}
+
+func holeStatus(h *Hole) string {
+	m := h.OccupyingMole
+	switch {
+	case h.State == Collapsed:
+		return "collapsed"
+	case h.State == Blocked:
+		return "blocked"
+	case m == nil || m.State == Dead:
+		return "empty"
+	case m.State == ExposedAlive:
+		return "exposed"
+	default:
+		return "hiding"
+	}
+}
+
+func (g *Game) handlePeek(args []string) {
+	r := g.Radar
+	if r.Charges == 0 {
+		g.respond("peek.empty", nil)
+		return
+	}
+	if wait := r.ReadyAt - g.Elapsed(); wait > 0 {
+		g.respond("peek.cooling", Fields{"wait": wait.Round(100 * time.Millisecond)})
+		return
+	}
+	region, err := g.parseRegion(args)
+	if err != nil {
+		g.respond("peek.bad_region", Fields{"region": args[0]})
+		return
+	}
+	if r.Charges > 0 {
+		r.Charges--
+	}
+	r.ReadyAt = g.Elapsed() + g.Config.PeekCooldown
+	r.RevealUntil = g.Ticks + 1
+	r.Region = region
+
+	var items []Fields
+	for id := 1; id <= g.HoleFactory.HoleId; id++ {
+		h := g.HoleFactory.HoleSet.GetHole(id)
+		if h == nil || (region != nil && !region[id]) {
+			continue
+		}
+		item := Fields{"hole": id, "status": word(holeStatus(h))}
+		if h.OccupyingMole != nil && h.OccupyingMole.State != Dead {
+			item["mole"] = h.OccupyingMole.ID
+			item["armored"] = h.OccupyingMole.Armored
+		}
+		items = append(items, item)
+	}
+	if r.Charges < 0 {
+		g.respondList("peek.free", nil, items)
+		return
+	}
+	g.respondList("peek", Fields{"charges": r.Charges}, items)
+}
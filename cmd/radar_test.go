@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeek(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	clock := NewManualClock(time.Unix(0, 0))
+	g.Clock = clock
+	g.Config.PeekCharges = 2
+	g.Init(4, 2)
+	g.MoleFactory.MoleSet.Housed[2].ToggleState()
+
+	g.ProcessPlayerInput("peek")
+	assert.Contains(t, buf.String(), "Radar sweep, 1 peeks left:\n  hole 1: hiding\n  hole 2: exposed\n  hole 3: empty\n  hole 4: empty\n")
+
+	buf.Reset()
+	g.ProcessPlayerInput("peek")
+	assert.Contains(t, buf.String(), "wait 5s")
+
+	clock.Advance(5 * time.Second)
+	g.ProcessPlayerInput("peek 50")
+	assert.Contains(t, buf.String(), "Can't point the radar at 50")
+	assert.Equal(t, 1, g.Radar.Charges, "a sweep of no holes costs nothing")
+	buf.Reset()
+	g.ProcessPlayerInput("peek 3-9")
+	assert.Contains(t, buf.String(), "0 peeks left:\n  hole 3: empty\n  hole 4: empty\n> ")
+
+	clock.Advance(5 * time.Second)
+	buf.Reset()
+	g.ProcessPlayerInput("peek")
+	assert.Contains(t, buf.String(), "out of charges")
+}
+
+func TestPeekRegion(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.Init(9, 0)
+	region, err := g.parseRegion([]string{"5"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]bool{2: true, 4: true, 5: true, 6: true, 8: true}, region)
+	_, err = g.parseRegion([]string{"5-2"})
+	assert.Error(t, err)
+	_, err = g.parseRegion([]string{"north"})
+	assert.Error(t, err)
+	_, err = g.parseRegion([]string{"20-30"})
+	assert.Error(t, err)
+	_, err = g.parseRegion([]string{"50"})
+	assert.Error(t, err)
+
+	// A huge range is cut down to the board rather than walked hole by hole.
+	region, err = g.parseRegion([]string{"0-2000000000"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]bool{1: true, 2: true, 3: true, 4: true, 5: true, 6: true, 7: true, 8: true, 9: true}, region)
+}
+
+func TestFog(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.Config.Fog = true
+	g.Config.Entropy = 100
+	g.Config.PeekCharges = -1
+	g.Config.PeekCooldown = 0
+	g.Init(3, 3)
+
+	g.ProcessTick()
+	assert.NotContains(t, buf.String(), "appeared")
+	assert.Empty(t, g.Observe().ExposedHoles())
+
+	g.ProcessPlayerInput("peek 1-1")
+	assert.Contains(t, buf.String(), "Radar sweep:\n  hole 1: exposed\n> ")
+	assert.Equal(t, []HoleView{{ID: 1, Occupied: true, Exposed: true}, {ID: 2}, {ID: 3}}, g.Observe().Holes)
+
+	buf.Reset()
+	g.ProcessTick()
+	assert.Contains(t, buf.String(), "appeared in hole 1")
+	assert.NotContains(t, buf.String(), "appeared in hole 3")
+
+	buf.Reset()
+	g.ProcessTick()
+	assert.NotContains(t, buf.String(), "appeared")
+}
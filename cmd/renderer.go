@@ -0,0 +1,485 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Record types.  Events happen on their own as the moles move, responses
+// answer a command and input echoes a scripted command into the transcript.
+const (
+	EventRecord    = "event"
+	ResponseRecord = "response"
+	InputRecord    = "input"
+)
+
+type Fields map[string]any
+
+// Record is a single thing the game has to say.  Key names the message and
+// Fields fill in its details; list style responses carry one entry per item.
+type Record struct {
+	Type   string
+	Key    string
+	Tick   int
+	At     time.Duration
+	Fields Fields
+	Items  []Fields
+}
+
+type Renderer interface {
+	Render(r Record)
+	Prompt()
+}
+
+func NewRenderer(name string, out io.Writer) (Renderer, error) {
+	switch name {
+	case "text":
+		return &TextRenderer{Out: out}, nil
+	case "json":
+		return &JSONRenderer{Out: out}, nil
+	case "quiet":
+		return QuietRenderer{}, nil
+	}
+	return nil, fmt.Errorf("unknown output mode %q", name)
+}
+
+var messages = map[string]string{
+	"game.welcome":           WelcomeMessage,
+	"game.help":              HelpMessage,
+	"game.won":               "Moles eliminated, YOU WIN!!!!\n",
+	"game.timeout":           "Time's up, the moles win! YOU LOSE!\n",
+	"game.quit":              "GOODBYE QUITTER!\n",
+	"script.command":         "@{at} {line}\n",
+	"script.exhausted":       "\nScript finished with moles still alive, YOU LOSE!\n",
+	"command.unknown":        "unknown commands\n",
+	"whack.no_hole":          "Hole ID Not Specified\n",
+	"whack.unknown_hole":     "SHLONK!\nHole ID not recognized, where are you aiming?!\n",
+	"whack.hit":              "SHLONK!\nbonked out of existence!\n",
+	"whack.miss":             "SHLONK!\nmissed and now its laughing!\n",
+	"whack.whiff":            "SHLONK!\nwhiff, no moles here!\n",
+	"whack.deflected":        "SHLONK!\nCLANG! the armor held, get a heavier hammer!\n",
+	"whack.sweep":            "SWOOSH!\n",
+	"whack.sweep.item":       "  hole {hole}: {result}\n",
+	"hammer.cooling":         "The {hammer} is still cooling down, wait {wait}!\n",
+	"hammer.list":            "Hammers (holding the {current}):\n",
+	"hammer.list.item":       "  {hammer}: cooldown {cooldown}, {status}\n",
+	"hammer.switched":        "You pick up the {hammer}.\n",
+	"hammer.unknown":         "You don't have a hammer called {hammer}!\n",
+	"peek":                   "Radar sweep, {charges} peeks left:\n",
+	"peek.item":              "  hole {hole}: {status}\n",
+	"peek.free":              "Radar sweep:\n",
+	"peek.free.item":         "  hole {hole}: {status}\n",
+	"peek.empty":             "The radar is out of charges!\n",
+	"peek.cooling":           "The radar is still warming up, wait {wait}!\n",
+	"peek.bad_region":        "Can't point the radar at {region}, try a hole like 4 or a range like 2-6.\n",
+	"stats":                  "Whacks: {whacks}  Hits: {hits}  Misses: {misses}  Whiffs: {whiffs}  Accuracy: {accuracy:%.0f}%\nScore: {score}  Longest streak: {longest_streak}  Best multiplier: x{max_multiplier:%.1f}  Frenzies: {frenzies}\n",
+	"stats.item":             "  {hammer}: {whacks} whacks, {hits} hits, {misses} misses ({deflected} off armor), {whiffs} whiffs\n",
+	"moles.stats":            "Alive: {alive}\nDead: {dead}\nTo win: {goal} dead\n",
+	"moles.stats_overrun":    "Alive: {alive}\nDead: {dead}\nTo win: {goal} dead\nOverrun: more than {overrun} alive\n",
+	"mole.born":              "mole {parent} had a pup, mole {mole} is loose!\n",
+	"game.overrun":           "{alive} moles! They've overrun the garden, YOU LOSE!\n",
+	"holes.list":             "",
+	"holes.list.item":        "hole: {id} ({state})\n",
+	"hole.collapsed":         "hole {hole} caved in! it won't be usable for {ticks} ticks.\n",
+	"hole.reopened":          "hole {hole} has been dug out again.\n",
+	"hole.opened":            "a new hole {hole} has opened up!\n",
+	"plug.placed":            "You plug hole {hole}. {plugs} plugs left.\n",
+	"plug.removed":           "You pull the plug out of hole {hole}. {plugs} plugs left.\n",
+	"plug.none":              "You're out of plugs!\n",
+	"plug.bad_hole":          "Can't plug that, pick an empty open hole.\n",
+	"plug.not_plugged":       "There's no plug in that hole.\n",
+	"plug.unknown_hole":      "There's no hole {hole}!\n",
+	"plug.no_hole":           "Which hole? Give a hole number.\n",
+	"map.open":               "There are no tunnels, moles can pop up in any hole!\n",
+	"map":                    "Tunnels ({topology}):\n",
+	"map.item":               "  hole {hole} ({state}) -> {links}\n",
+	"map.grid":               "Tunnels ({topology}):\n{drawing}",
+	"events.throttled":       "...and {dropped} more things happened that you didn't catch.\n",
+	"pause.paused":           "Paused at {elapsed}. Type resume to carry on.\n",
+	"pause.idle":             "Nothing from you in {idle}, so the game is paused. Type resume to carry on.\n",
+	"pause.resumed":          "Back to whacking!\n",
+	"pause.already":          "The game is already paused.\n",
+	"pause.not_paused":       "The game isn't paused.\n",
+	"pause.blocked":          "The game is paused, type resume first.\n",
+	"history":                "Mole {mole}:\n",
+	"history.item":           "  {at:%8v}  {event} ({where})\n",
+	"history.truncated":      "Mole {mole} ({dropped} older events after its spawn not kept):\n",
+	"history.truncated.item": "  {at:%8v}  {event} ({where})\n",
+	"history.no_mole":        "Which mole? Give a mole number.\n",
+	"history.unknown_mole":   "There's no mole {mole}!\n",
+	"reactions":              "Reaction times over {count} hits: mean {mean}, fastest {fastest}, slowest {slowest}\n",
+	"reactions.item":         "  {range:%-12s} {bar} {count}\n",
+	"combo.streak":           "{streak} in a row! x{multiplier:%.1f} for {points} points, {score} in total.\n",
+	"combo.broken":           "Streak of {streak} broken!\n",
+	"combo.frenzy":           "FRENZY! {streak} in a row, {bonus} bonus points!\n",
+	"difficulty.changed":     "Difficulty {direction} (hit rate {hit_rate:%.0f}%): entropy {entropy}, moles stay out {expose_ticks} ticks, tick {tick}\n",
+	"difficulty":             "Entropy {entropy}, moles stay out {expose_ticks} ticks, tick {tick}, aiming for {target:%.0f}% hits\n",
+	"difficulty.item":        "  @{at} {direction}: hit rate {hit_rate:%.0f}%, reaction {reaction} -> entropy {entropy}, {expose_ticks} ticks out, tick {tick}\n",
+	"difficulty.fixed":       "Entropy {entropy}, tick {tick}, adaptive difficulty is off.\n",
+	"versus.moles":           "[moles] Your moles:\n",
+	"versus.moles.item":      "  mole {mole}: {status}\n",
+	"versus.none_left":       "[moles] All your moles have been whacked!\n",
+	"versus.no_mole":         "[moles] You have no mole {mole} to move.\n",
+	"versus.not_housed":      "[moles] mole {mole} is underground, tunnel to a hole first.\n",
+	"versus.already":         "[moles] mole {mole} is already {state}.\n",
+	"versus.hidden":          "[moles] mole {mole} ducks down in hole {hole}.\n",
+	"versus.exposed":         "[moles] mole {mole} pops up in hole {hole}!\n",
+	"versus.no_hole":         "[moles] Which hole? Give a hole number.\n",
+	"versus.bad_hole":        "[moles] There's no hole {hole}!\n",
+	"versus.blocked":         "[moles] hole {hole} isn't free, mole {mole} stays put.\n",
+	"versus.no_tunnel":       "[moles] There's no tunnel from hole {from} to hole {hole}.\n",
+	"versus.tunneling":       "[moles] mole {mole} heads down the tunnel to hole {hole}, {ticks} ticks away.\n",
+	"versus.tunneled":        "[moles] mole {mole} tunnels over to hole {hole}.\n",
+	"versus.travelling":      "[moles] mole {mole} is still travelling, wait for it to come up.\n",
+	"versus.hammer":          "[moles] The whacker holds the {hammer} ({status}), last swing at {last}.\n",
+	"versus.unknown":         "[moles] Unknown command {command}, try hide, expose, tunnel, peek-hammer or moles.\n",
+	"achievement.unlocked":   "*** Achievement unlocked: {name}, {description}! ***\n",
+	"achievements":           "{unlocked} of {total} achievements unlocked:\n",
+	"achievements.item":      "  {name}: {description} ({status})\n",
+	"achievements.off":       "Achievements aren't being kept for this game.\n",
+
+	// The built in achievements.
+	"achievement.sharpshooter.name":        "Sharpshooter",
+	"achievement.sharpshooter.description": "Clear a board with 100% accuracy",
+	"achievement.hat_trick.name":           "Hat trick",
+	"achievement.hat_trick.description":    "Whack 3 moles within 2 seconds",
+	"achievement.clean_sweep.name":         "Clean sweep",
+	"achievement.clean_sweep.description":  "Win without a single whiff",
+	"achievement.survivor.name":            "Survivor",
+	"achievement.survivor.description":     "Survive 10 minutes in survival mode",
+	"achievement.exterminator.name":        "Exterminator",
+	"achievement.exterminator.description": "Whack 100 moles",
+
+	"survival.minute":        "You've survived {minutes} minutes!\n",
+	"daily.start":            "Daily challenge for {date}: {mode} mode, {holes} holes, {moles} moles ({armored} armored), entropy {entropy}.\n",
+	"daily.practice":         "{player} has already played today, this attempt won't be ranked.\n",
+	"daily.leaderboard":      "Leaderboard for {date}:\n",
+	"daily.leaderboard.item": "  {rank}. {player}: {outcome}, {score} points in {elapsed}\n",
+	"debug.spawned":          "[debug] spawned {count} moles: {moles}\n",
+	"debug.mole":             "[debug] mole {mole} is {status}\n",
+	"debug.no_mole":          "[debug] no living mole {mole}\n",
+	"debug.not_housed":       "[debug] mole {mole} isn't in a hole\n",
+	"debug.bad_hole":         "[debug] hole {hole} isn't an empty hole\n",
+	"debug.bad_count":        "[debug] can't spawn {count} moles\n",
+	"debug.freeze":           "[debug] frozen: {frozen}\n",
+	"debug.stepped":          "[debug] stepped to tick {tick}\n",
+	"debug.holes":            "[debug] {count} holes:\n",
+	"debug.holes.item":       "  hole {hole}: {state}, mole {mole}, in {sets}\n",
+	"debug.moles":            "[debug] {count} moles, {win} to win, tick {tick}, frozen: {frozen}\n",
+	"debug.moles.item":       "  mole {mole}: {status}, in {sets}{flags}\n",
+	"debug.seed":             "[debug] seed {seed}\n",
+	"debug.bad_seed":         "[debug] bad seed {seed}\n",
+	"daily.debug":            "Debug commands are on, this attempt won't be ranked.\n",
+	"daily.replay":           "Replay saved to {path}\n",
+	"items":                  "Items:\n",
+	"items.item":             "  {item}: {left} left{placed}\n",
+	"items.none":             "You don't have any items this game.\n",
+	"item.none":              "You're out of {item}!\n",
+	"item.bad_hole":          "Can't put {item} there, pick an empty open hole without anything in it.\n",
+	"item.no_hole":           "Where should the {item} go? Give a hole number.\n",
+	"item.unknown_hole":      "There's no hole {hole} to put {item} in!\n",
+	"item.no_region":         "Smoke out where? Give a hole or a range like 2-5.\n",
+	"item.bad_region":        "Can't smoke out {region}, give a hole or a range like 2-5.\n",
+	"item.placed":            "You set {item} in hole {hole}. {left} left.\n",
+	"item.smoked":            "POOF! {moles} moles smoked out. {left} smoke bombs left.\n",
+	"item.trapped":           "SNAP! mole {mole} walked into the trap in hole {hole}!\n",
+	"item.lured":             "mole {mole} went for the bait in hole {hole}.\n",
+	"stats.items":            "Traps: {traps} set, {caught} caught  Bait: {baits} set, {lured} lured  Smoke: {smokes} used, {smoked} smoked out\n",
+	"board.earthquake":       "EARTHQUAKE! The ground shakes and {moles} moles scramble for new holes!\n",
+	"board.flood":            "FLOOD! Row {row} is under water for {ticks} ticks!\n",
+	"board.frenzy":           "MOLE FRENZY! {moles} moles pop up at once!\n",
+	"board.fog":              "Fog rolls in, you won't see moles come up for {ticks} ticks.\n",
+	"board.fog_lifted":       "The fog lifts.\n",
+	"net.welcome":            "You are {player}.  Type \"name <new name>\" to change it and \"scores\" to see how everyone is doing.\n",
+	"net.joined":             "{player} joined the game.\n",
+	"net.left":               "{player} left the game.\n",
+	"net.renamed":            "{old} is now {player}.\n",
+	"net.name_taken":         "Someone is already called {player}.\n",
+	"net.no_name":            "Name yourself what?\n",
+	"net.kill":               "{player} whacked mole {mole} in hole {hole}!\n",
+	"net.beaten":             "Too slow, {player} got to hole {hole} first.\n",
+	"net.scores":             "Scores:\n",
+	"net.scores.item":        "  {player}: {score} points, {kills} kills, {hits}/{whacks} hits ({accuracy:%.0f}%)\n",
+	"net.mole_player":        "{player} is playing the moles.\n",
+	"net.mole_taken":         "{player} is already playing the moles.\n",
+	"net.mole_only":          "You're playing the moles, leave the whacking and trapping to the others.\n",
+	"net.not_mole_player":    "You aren't playing the moles.\n",
+	"net.mole_released":      "{player} stops playing the moles.\n",
+	"net.no_pause":           "The game can't be paused with other people playing.\n",
+	"net.bye":                "Bye {player}!\n",
+	"net.spectators":         "{count} watching.\n",
+	"spectate.welcome":       "You are watching, {delay} behind the game.  Players: {players}\n",
+	"spectate.read_only":     "Spectators can't play, just watch.\n",
+	"spectate.board":         "Board:\n",
+	"spectate.board.item":    "  hole {hole}: {status}\n",
+	"mole.vanished":          "mole {mole} vanished!\n",
+	"mole.appeared":          "mole {mole} appeared in hole {hole}!\n",
+	"mole.appeared_armored":  "armored mole {mole} appeared in hole {hole}!\n",
+	"word.bonked":            "bonked",
+	"word.missed":            "missed",
+	"word.clang":             "clang",
+	"word.whiff":             "whiff",
+	"word.glanced":           "glanced",
+	"word.ready":             "ready",
+	"word.cooling":           "cooling {wait}",
+	"word.cooling_for":       "cooling for {wait}",
+	"word.nowhere_yet":       "nowhere yet",
+	"word.hole":              "hole {hole}",
+	"word.open":              "open",
+	"word.collapsed":         "collapsed",
+	"word.blocked":           "blocked",
+	"word.empty":             "empty",
+	"word.exposed":           "exposed",
+	"word.hiding":            "hiding",
+	"word.whacked":           "whacked",
+	"word.underground":       "underground",
+	"word.exposed_in":        "exposed in hole {hole}",
+	"word.hiding_in":         "hiding in hole {hole}",
+	"word.mole_status":       "mole {mole} {status}",
+	"word.spawned":           "spawned",
+	"word.housed":            "housed",
+	"word.hidden":            "hidden",
+	"word.left":              "left",
+	"word.trapped":           "trapped",
+	"word.died":              "died",
+	"word.harder":            "harder",
+	"word.easier":            "easier",
+	"word.hold":              "steady",
+	"word.won":               "won",
+	"word.lost":              "lost",
+	"word.quit":              "quit",
+	"word.undecided":         "undecided",
+	"word.classic":           "classic",
+	"word.survival":          "survival",
+	"word.locked":            "locked",
+	"word.unlocked":          "unlocked {date}",
+	"word.set_in":            ", set in hole {holes}",
+
+	// Accessibility mode prompts with a word and says more when a mole
+	// comes up.
+	"prompt":                        "> ",
+	"prompt.accessible":             "Command: ",
+	"mole.appeared.verbose":         "Mole up! mole {mole} is out of hole {hole}, whack {hole} now.\n",
+	"mole.appeared_armored.verbose": "Armored mole up! mole {mole} is out of hole {hole}, it needs the heavy hammer.\n",
+	"board.frenzy.verbose":          "Mole frenzy! {moles} moles are up at once, check the holes.\n",
+}
+
+// formatMessage fills {name} placeholders from fields.  A placeholder may
+// carry a fmt verb, as in {accuracy:%.1f}.
+func formatMessage(tmpl string, f Fields) string {
+	return formatWith(tmpl, f, (*Catalog)(nil).Text)
+}
+
+// formatWith is formatMessage with Text fields filled in by text.
+func formatWith(tmpl string, f Fields, text func(Text) string) string {
+	var b strings.Builder
+	for {
+		open := strings.IndexByte(tmpl, '{')
+		if open < 0 {
+			break
+		}
+		close := strings.IndexByte(tmpl[open:], '}')
+		if close < 0 {
+			break
+		}
+		close += open
+		b.WriteString(tmpl[:open])
+		name, verb, ok := strings.Cut(tmpl[open+1:close], ":")
+		if !ok {
+			verb = "%v"
+		}
+		if v, found := f[name]; found {
+			if t, ok := v.(Text); ok {
+				v = text(t)
+			}
+			fmt.Fprintf(&b, verb, v)
+		} else {
+			b.WriteString(tmpl[open : close+1])
+		}
+		tmpl = tmpl[close+1:]
+	}
+	b.WriteString(tmpl)
+	return b.String()
+}
+
+// TextRenderer writes messages from Catalog, or in English if it has none,
+// coloured with Theme if it has one.  An Accessible renderer rings the bell
+// and uses the ".verbose" form of a message when a mole comes up, and
+// prompts with a word rather than a symbol.
+type TextRenderer struct {
+	Out        io.Writer
+	Catalog    *Catalog
+	Theme      Theme
+	Accessible bool
+	prompting  bool
+}
+
+// Render writes the whole record at once, so a list response costs a single
+// write however many items it has.  An event that arrives while the prompt
+// is waiting starts on a line of its own, so it doesn't run into whatever
+// the player is typing.
+func (t *TextRenderer) Render(r Record) {
+	var b strings.Builder
+	if t.prompting && r.Type == EventRecord {
+		b.WriteString("\n")
+	}
+	t.prompting = false
+	key := r.Key
+	if t.Accessible {
+		if _, ok := t.Catalog.Message(key + ".verbose"); ok {
+			key += ".verbose"
+		}
+		if alerts[r.Key] {
+			b.WriteString("\a")
+		}
+	}
+	if tmpl, ok := t.Catalog.Message(key); ok {
+		b.WriteString(t.Theme.paint(r.Key, formatWith(tmpl, r.Fields, t.text)))
+	} else {
+		fmt.Fprintf(&b, "%s\n", r.Key)
+	}
+	item, _ := t.Catalog.Message(r.Key + ".item")
+	for _, f := range r.Items {
+		b.WriteString(formatWith(item, f, t.text))
+	}
+	io.WriteString(t.Out, b.String())
+}
+
+func (t *TextRenderer) text(v Text) string {
+	tmpl, ok := t.Catalog.Message(v.Key)
+	if !ok {
+		return v.Key
+	}
+	return t.Theme.paint(v.Key, formatWith(tmpl, v.Fields, t.text))
+}
+
+// Prompt does nothing if the prompt is already showing, so it can be called
+// after every tick to put it back once events have been written.
+func (t *TextRenderer) Prompt() {
+	if t.prompting {
+		return
+	}
+	key := "prompt"
+	if t.Accessible {
+		key = "prompt.accessible"
+	}
+	tmpl, _ := t.Catalog.Message(key)
+	io.WriteString(t.Out, tmpl)
+	t.prompting = true
+}
+
+// JSONRenderer writes one JSON object per record.  Durations are written as
+// seconds so consumers do not need to know Go's representation, and Text
+// fields in English.
+type JSONRenderer struct {
+	Out io.Writer
+}
+
+type jsonRecord struct {
+	Type   string   `json:"type"`
+	Key    string   `json:"key"`
+	Tick   int      `json:"tick"`
+	At     float64  `json:"at"`
+	Fields Fields   `json:"fields,omitempty"`
+	Items  []Fields `json:"items,omitempty"`
+}
+
+func jsonFields(f Fields) Fields {
+	if f == nil {
+		return nil
+	}
+	out := make(Fields, len(f))
+	for k, v := range f {
+		switch x := v.(type) {
+		case time.Duration:
+			v = x.Seconds()
+		case Text:
+			v = x.String()
+		}
+		out[k] = v
+	}
+	return out
+}
+
+func (j *JSONRenderer) Render(r Record) {
+	rec := jsonRecord{Type: r.Type, Key: r.Key, Tick: r.Tick, At: r.At.Seconds(), Fields: jsonFields(r.Fields)}
+	for _, f := range r.Items {
+		rec.Items = append(rec.Items, jsonFields(f))
+	}
+	b, err := json.Marshal(rec)
+	if err != nil {
+		b, _ = json.Marshal(jsonRecord{Type: r.Type, Key: r.Key, Tick: r.Tick, At: r.At.Seconds()})
+	}
+	j.Out.Write(append(b, '\n'))
+}
+
+func (j *JSONRenderer) Prompt() {}
+
+type QuietRenderer struct{}
+
+func (QuietRenderer) Render(Record) {}
+
+func (QuietRenderer) Prompt() {}
+
+func (g *Game) record(typ string, key string, f Fields, items []Fields) {
+	r := Record{Type: typ, Key: key, Tick: g.Ticks, At: g.Elapsed(), Fields: f, Items: items}
+	g.observe(r)
+	if g.batching && typ == EventRecord {
+		g.pending = append(g.pending, r)
+		return
+	}
+	g.Renderer.Render(r)
+}
+
+// throttles reports whether a record counts towards the event limit.  Game
+// and board events always get through.
+func throttles(key string) bool {
+	return !strings.HasPrefix(key, "game.") && !strings.HasPrefix(key, "board.")
+}
+
+// flushEvents renders the events held back during a tick.  Past
+// Config.EventLimit the mole and hole events are dropped and counted in a
+// single "events.throttled" record, so a big board can't flood the output.
+func (g *Game) flushEvents() {
+	limit := g.Config.EventLimit
+	dropped := 0
+	if limit > 0 {
+		shown := 0
+		for _, r := range g.pending {
+			if throttles(r.Key) {
+				shown++
+			}
+		}
+		dropped = max(shown-limit, 0)
+	}
+	shown := 0
+	for _, r := range g.pending {
+		if throttles(r.Key) && dropped > 0 {
+			shown++
+			if shown == limit+1 {
+				g.Renderer.Render(Record{Type: EventRecord, Key: "events.throttled", Tick: r.Tick, At: r.At, Fields: Fields{"dropped": dropped}})
+			}
+			if shown > limit {
+				continue
+			}
+		}
+		g.Renderer.Render(r)
+	}
+	clear(g.pending)
+	g.pending = g.pending[:0]
+}
+
+func (g *Game) emit(key string, f Fields) {
+	g.record(EventRecord, key, f, nil)
+}
+
+func (g *Game) respond(key string, f Fields) {
+	g.record(ResponseRecord, key, f, nil)
+}
+
+func (g *Game) respondList(key string, f Fields, items []Fields) {
+	g.record(ResponseRecord, key, f, items)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatMessage(t *testing.T) {
+	f := Fields{"mole": 3, "hole": 7, "accuracy": 0.6667}
+	assert.Equal(t, "mole 3 appeared in hole 7!\n", formatMessage(messages["mole.appeared"], f))
+	assert.Equal(t, "hit 0.67 of {missing}", formatMessage("hit {accuracy:%.2f} of {missing}", f))
+	assert.Equal(t, "unclosed {brace", formatMessage("unclosed {brace", f))
+}
+
+func TestJSONRenderer(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.Renderer = &JSONRenderer{Out: &buf}
+	g.Init(3, 3)
+	g.MoleFactory.MoleSet.Housed[2].ToggleState()
+	g.Start()
+	g.ProcessPlayerInput("whack 2")
+	g.ProcessPlayerInput("holes")
+	g.respond("test.duration", Fields{"took": 1500 * time.Millisecond})
+
+	var recs []jsonRecord
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var rec jsonRecord
+		require.NoError(t, json.Unmarshal([]byte(line), &rec))
+		recs = append(recs, rec)
+	}
+	require.Len(t, recs, 4)
+	assert.Equal(t, "game.welcome", recs[0].Key)
+	assert.Equal(t, ResponseRecord, recs[1].Type)
+	assert.Equal(t, "whack.hit", recs[1].Key)
+	assert.Equal(t, Fields{"hammer": "mallet", "hole": 2.0, "mole": 2.0}, recs[1].Fields)
+	assert.Len(t, recs[2].Items, 3)
+	assert.Equal(t, 1.5, recs[3].Fields["took"])
+}
+
+func TestQuietRenderer(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	r, err := NewRenderer("quiet", &buf)
+	require.NoError(t, err)
+	g.Renderer = r
+	g.Init(3, 3)
+	g.Start()
+	g.ProcessPlayerInput("moles")
+	assert.Empty(t, buf.String())
+
+	_, err = NewRenderer("hologram", &buf)
+	require.Error(t, err)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var boardSizes = []int{1000, 10000, 100000}
+
+func bigGame(n int, selection string) *Game {
+	g := NewGame(io.Discard)
+	g.Renderer = QuietRenderer{}
+	g.Rand = rand.New(rand.NewSource(1))
+	g.Config.Selection = selection
+	g.Init(n, n/2)
+	return g
+}
+
+func BenchmarkProcessTick(b *testing.B) {
+	for _, n := range boardSizes {
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			g := bigGame(n, "")
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				g.ProcessTick()
+			}
+		})
+	}
+}
+
+func BenchmarkProcessTickText(b *testing.B) {
+	for _, n := range boardSizes {
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			g := bigGame(n, "")
+			g.Renderer = &TextRenderer{Out: io.Discard}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				g.ProcessTick()
+			}
+		})
+	}
+}
+
+func BenchmarkSelectHole(b *testing.B) {
+	for _, policy := range []string{"first", "uniform", "lru", "round-robin"} {
+		for _, n := range boardSizes {
+			b.Run(policy+"/"+strconv.Itoa(n), func(b *testing.B) {
+				g := bigGame(n, policy)
+				moles := g.MoleFactory.MoleSet.Index.Moles
+				hs := &g.HoleFactory.HoleSet
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					moles[i%len(moles)].Tunnel(hs)
+				}
+			})
+		}
+	}
+}
+
+func TestBigBoardTick(t *testing.T) {
+	g := bigGame(100000, "uniform")
+	ms := &g.MoleFactory.MoleSet
+	for range 5 {
+		g.ProcessTick()
+	}
+	assert.Len(t, ms.Housed, 50000)
+	assert.Len(t, g.HoleFactory.HoleSet.Available, 50000)
+	assert.Len(t, g.HoleFactory.HoleSet.Index.Open, 50000)
+}
+
+func TestEventsThrottled(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.Rand = rand.New(rand.NewSource(1))
+	g.Config.Entropy = 100
+	g.Config.EventLimit = 5
+	g.Init(40, 20)
+	g.ProcessTick()
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 6)
+	assert.Regexp(t, `^\.\.\.and \d+ more things happened`, lines[5])
+
+	buf.Reset()
+	g.Config.EventLimit = 0
+	g.ProcessTick()
+	assert.Greater(t, strings.Count(buf.String(), "\n"), 6)
+}
+
+func TestGameEventsNotThrottled(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.Rand = rand.New(rand.NewSource(1))
+	g.Config.Entropy = 100
+	g.Config.EventLimit = 1
+	g.Config.Breeding = Breeding{Overrun: 1}
+	g.Init(10, 5)
+	g.ProcessTick()
+	assert.Contains(t, buf.String(), "more things happened")
+	assert.True(t, strings.HasSuffix(buf.String(), "They've overrun the garden, YOU LOSE!\n"))
+}
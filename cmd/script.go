@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ScriptCommand is one line of a script: a player command and the point in
+// game time at which it is typed, written as "@1.5s whack 2".  A line without
+// a time runs at the same time as the line before it.
+type ScriptCommand struct {
+	At   time.Duration
+	Line string
+}
+
+func ParseScript(r io.Reader) ([]ScriptCommand, error) {
+	var cmds []ScriptCommand
+	var at time.Duration
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "@") {
+			stamp, rest, _ := strings.Cut(line[1:], " ")
+			d, err := time.ParseDuration(stamp)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: bad time %q", lineNo, stamp)
+			}
+			if d < at {
+				return nil, fmt.Errorf("line %d: time %s is before %s", lineNo, d, at)
+			}
+			at = d
+			line = strings.TrimSpace(rest)
+			if line == "" {
+				return nil, fmt.Errorf("line %d: missing command", lineNo)
+			}
+		}
+		cmds = append(cmds, ScriptCommand{At: at, Line: line})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return cmds, nil
+}
+
+// RunScript plays the commands against the game, moving the clock forward and
+// processing every tick that falls due before each command.  Script times
+// are wall clock times, while ticks fall due in game time, so a pause pushes
+// the remaining ticks back by as long as it lasted.  A script that runs out
+// while moles are still alive counts as a loss.
+func (g *Game) RunScript(cmds []ScriptCommand, clock *ManualClock) {
+	next := g.Config.Tick
+	for _, c := range cmds {
+		for g.State != End && !g.Paused && next+g.PausedFor <= c.At {
+			clock.Set(g.StartTime.Add(next + g.PausedFor))
+			g.ProcessTick()
+			if !g.Paused {
+				next += g.Config.Tick
+			}
+		}
+		if g.State == End {
+			return
+		}
+		clock.Set(g.StartTime.Add(c.At))
+		g.record(InputRecord, "script.command", Fields{"at": c.At, "line": c.Line}, nil)
+		g.ProcessPlayerInput(c.Line)
+		if g.State == End {
+			return
+		}
+	}
+	g.emit("script.exhausted", nil)
+	g.end(Lost)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseScript(t *testing.T) {
+	cmds, err := ParseScript(strings.NewReader("# warm up\nmoles\n\n@1.5s whack 2\nholes\n@3s quit\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []ScriptCommand{
+		{At: 0, Line: "moles"},
+		{At: 1500 * time.Millisecond, Line: "whack 2"},
+		{At: 1500 * time.Millisecond, Line: "holes"},
+		{At: 3 * time.Second, Line: "quit"},
+	}, cmds)
+
+	_, err = ParseScript(strings.NewReader("@2s moles\n@1s holes\n"))
+	require.Error(t, err)
+	_, err = ParseScript(strings.NewReader("@soon whack 1\n"))
+	require.Error(t, err)
+	_, err = ParseScript(strings.NewReader("@1s\n"))
+	require.Error(t, err)
+}
+
+func TestRunScriptOutcomes(t *testing.T) {
+	newScriptGame := func(entropy int) (*Game, *ManualClock, *bytes.Buffer) {
+		var buf bytes.Buffer
+		g := NewGame(&buf)
+		clock := NewManualClock(time.Unix(0, 0))
+		g.Clock = clock
+		g.Config.Entropy = entropy
+		g.Init(3, 3)
+		g.Start()
+		return g, clock, &buf
+	}
+
+	// With full entropy every mole is exposed after the first tick.
+	g, clock, buf := newScriptGame(100)
+	cmds, _ := ParseScript(strings.NewReader("@1s whack 1\nwhack 2\nwhack 3\n@9s moles\n"))
+	g.RunScript(cmds, clock)
+	assert.Equal(t, Won, g.Outcome)
+	assert.Equal(t, ExitWin, g.ExitCode())
+	assert.Equal(t, time.Second, g.Elapsed())
+	assert.Contains(t, buf.String(), "@1s whack 3")
+	assert.NotContains(t, buf.String(), "@9s moles")
+
+	g, clock, _ = newScriptGame(0)
+	cmds, _ = ParseScript(strings.NewReader("@2s whack 1\n"))
+	g.RunScript(cmds, clock)
+	assert.Equal(t, ExitLose, g.ExitCode())
+
+	g, clock, _ = newScriptGame(0)
+	cmds, _ = ParseScript(strings.NewReader("@2s quit\n"))
+	g.RunScript(cmds, clock)
+	assert.Equal(t, ExitQuit, g.ExitCode())
+
+	g, clock, _ = newScriptGame(0)
+	g.Config.TimeLimit = 5 * time.Second
+	cmds, _ = ParseScript(strings.NewReader("@10s quit\n"))
+	g.RunScript(cmds, clock)
+	assert.Equal(t, Lost, g.Outcome)
+	assert.Equal(t, 5*time.Second, g.Elapsed())
+}
+
+func TestRunWithScriptFlag(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "moves.txt")
+	transcript := filepath.Join(dir, "moves.log")
+	require.NoError(t, os.WriteFile(script, []byte("@1s whack 1\n@1s whack 2\n@1s whack 3\n"), 0o644))
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-script", script, "-transcript", transcript, "-entropy", "100"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, ExitWin, code)
+	b, err := os.ReadFile(transcript)
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "YOU WIN")
+
+	code = run([]string{"-script", filepath.Join(dir, "missing.txt")}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, ExitError, code)
+}
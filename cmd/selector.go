@@ -0,0 +1,169 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// HoleSelector decides which open hole a mole comes up in.  Select is only
+// called when at least one hole is open.
+type HoleSelector interface {
+	Select(ix *HoleIndex) int
+}
+
+// openWatcher is implemented by selectors that need to hear about every hole
+// that opens up.
+type openWatcher interface {
+	Opened(ix *HoleIndex, id int)
+}
+
+type firstSelector struct{}
+
+func (firstSelector) Select(ix *HoleIndex) int {
+	return ix.NextOpen(1)
+}
+
+type uniformSelector struct {
+	rng *rand.Rand
+}
+
+func (s *uniformSelector) Select(ix *HoleIndex) int {
+	return ix.Open[s.rng.Intn(len(ix.Open))]
+}
+
+// lruSelector picks the hole that has gone longest without a mole, holes
+// that have never had one first.  Each hole that opens is pushed onto a heap
+// ordered by when it was last used; entries for holes that have since closed
+// or been used again are dropped when they reach the top.
+type lruSelector struct {
+	queue lruQueue
+}
+
+type lruEntry struct {
+	id   int
+	used int
+}
+
+type lruQueue []lruEntry
+
+func (q lruQueue) Len() int { return len(q) }
+func (q lruQueue) Less(i, j int) bool {
+	if q[i].used != q[j].used {
+		return q[i].used < q[j].used
+	}
+	return q[i].id < q[j].id
+}
+func (q lruQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *lruQueue) Push(x any)   { *q = append(*q, x.(lruEntry)) }
+func (q *lruQueue) Pop() any {
+	old := *q
+	e := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return e
+}
+
+func (s *lruSelector) Opened(ix *HoleIndex, id int) {
+	heap.Push(&s.queue, lruEntry{id: id, used: ix.LastUsed[id]})
+}
+
+func (s *lruSelector) Select(ix *HoleIndex) int {
+	for s.queue.Len() > 0 {
+		e := s.queue[0]
+		if ix.IsOpen(e.id) && ix.LastUsed[e.id] == e.used {
+			return e.id
+		}
+		heap.Pop(&s.queue)
+	}
+	return ix.NextOpen(1)
+}
+
+// weightedSelector picks holes in proportion to their weight.  If every open
+// hole has a weight of zero it falls back to picking uniformly.  Unlike the
+// other policies it looks at every open hole, so it is slower on big boards.
+type weightedSelector struct {
+	rng *rand.Rand
+}
+
+func (s *weightedSelector) Select(ix *HoleIndex) int {
+	total := 0.0
+	for _, id := range ix.Open {
+		total += ix.Weight(id)
+	}
+	if total <= 0 {
+		return ix.Open[s.rng.Intn(len(ix.Open))]
+	}
+	r := s.rng.Float64() * total
+	for _, id := range ix.Open {
+		r -= ix.Weight(id)
+		if r < 0 {
+			return id
+		}
+	}
+	return ix.Open[len(ix.Open)-1]
+}
+
+// roundRobinSelector works its way up through the holes, going back to the
+// lowest one after the highest.
+type roundRobinSelector struct {
+	last int
+}
+
+func (s *roundRobinSelector) Select(ix *HoleIndex) int {
+	id := ix.NextOpen(s.last + 1)
+	if id == 0 {
+		id = ix.NextOpen(1)
+	}
+	s.last = id
+	return id
+}
+
+var HoleSelectors = map[string]func(rng *rand.Rand) HoleSelector{
+	"first": func(*rand.Rand) HoleSelector {
+		return firstSelector{}
+	},
+	"uniform": func(rng *rand.Rand) HoleSelector {
+		return &uniformSelector{rng: rng}
+	},
+	"lru": func(*rand.Rand) HoleSelector {
+		return &lruSelector{}
+	},
+	"weighted": func(rng *rand.Rand) HoleSelector {
+		return &weightedSelector{rng: rng}
+	},
+	"round-robin": func(*rand.Rand) HoleSelector {
+		return &roundRobinSelector{}
+	},
+}
+
+func NewHoleSelector(name string, rng *rand.Rand) (HoleSelector, error) {
+	newSelector, ok := HoleSelectors[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown hole selection %q", name)
+	}
+	return newSelector(rng), nil
+}
+
+// ParseWeights reads hole weights written as "1:3,4:0.5".
+func ParseWeights(s string) (map[int]float64, error) {
+	weights := make(map[int]float64)
+	if s == "" {
+		return weights, nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		id, w, ok := strings.Cut(strings.TrimSpace(part), ":")
+		hole, err := strconv.Atoi(id)
+		if !ok || err != nil || hole < 1 {
+			return nil, fmt.Errorf("bad hole weight %q", part)
+		}
+		weight, err := strconv.ParseFloat(w, 64)
+		if err != nil || weight < 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return nil, fmt.Errorf("bad hole weight %q", part)
+		}
+		weights[hole] = weight
+	}
+	return weights, nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newIndex(sel HoleSelector, open ...int) *HoleIndex {
+	ix := NewHoleIndex()
+	ix.Selector = sel
+	for _, id := range open {
+		ix.open(id)
+	}
+	return ix
+}
+
+func TestHoleIndexOpenAndClose(t *testing.T) {
+	ix := newIndex(nil, 4, 1, 3, 1)
+	assert.ElementsMatch(t, []int{1, 3, 4}, ix.Open)
+	ix.close(3)
+	ix.close(7)
+	assert.ElementsMatch(t, []int{1, 4}, ix.Open)
+	assert.True(t, ix.IsOpen(4))
+	assert.False(t, ix.IsOpen(3))
+	assert.Equal(t, 1, ix.Select())
+	ix.close(1)
+	assert.Equal(t, 4, ix.Select())
+	assert.Equal(t, 0, newIndex(nil).Select())
+}
+
+func TestHoleIndexNextOpen(t *testing.T) {
+	ix := newIndex(nil, 3, 70, 5000, 9000)
+	assert.Equal(t, 3, ix.NextOpen(0))
+	assert.Equal(t, 3, ix.NextOpen(3))
+	assert.Equal(t, 70, ix.NextOpen(4))
+	assert.Equal(t, 5000, ix.NextOpen(71))
+	assert.Equal(t, 9000, ix.NextOpen(5001))
+	assert.Equal(t, 0, ix.NextOpen(9001))
+	assert.Equal(t, 0, ix.NextOpen(100000))
+	ix.close(5000)
+	assert.Equal(t, 9000, ix.NextOpen(71))
+}
+
+func TestUniformSelector(t *testing.T) {
+	ix := newIndex(&uniformSelector{rng: rand.New(rand.NewSource(1))}, 1, 2, 3, 4)
+	counts := make(map[int]int)
+	for i := 0; i < 4000; i++ {
+		counts[ix.Select()]++
+	}
+	for id := 1; id <= 4; id++ {
+		assert.InDelta(t, 1000, counts[id], 150, "hole %d", id)
+	}
+}
+
+func TestLRUSelector(t *testing.T) {
+	ix := newIndex(&lruSelector{}, 1, 2, 3)
+	occupy := func(id int) {
+		ix.close(id)
+		ix.Touch(id)
+	}
+	assert.Equal(t, 1, ix.Select())
+	occupy(1)
+	occupy(3)
+	assert.Equal(t, 2, ix.Select())
+	occupy(2)
+	ix.open(3)
+	ix.open(1)
+	assert.Equal(t, 1, ix.Select())
+	occupy(1)
+	assert.Equal(t, 3, ix.Select())
+}
+
+func TestWeightedSelector(t *testing.T) {
+	ix := newIndex(&weightedSelector{rng: rand.New(rand.NewSource(1))}, 1, 2, 3)
+	ix.Weights = map[int]float64{1: 3, 3: 0}
+	counts := make(map[int]int)
+	for i := 0; i < 4000; i++ {
+		counts[ix.Select()]++
+	}
+	assert.InDelta(t, 3000, counts[1], 150)
+	assert.InDelta(t, 1000, counts[2], 150)
+	assert.Zero(t, counts[3])
+
+	ix.Weights = map[int]float64{1: 0, 2: 0, 3: 0}
+	assert.Contains(t, []int{1, 2, 3}, ix.Select())
+}
+
+func TestRoundRobinSelector(t *testing.T) {
+	ix := newIndex(&roundRobinSelector{}, 1, 2, 4)
+	var picks []int
+	for i := 0; i < 4; i++ {
+		picks = append(picks, ix.Select())
+	}
+	assert.Equal(t, []int{1, 2, 4, 1}, picks)
+	ix.close(2)
+	assert.Equal(t, 4, ix.Select())
+}
+
+func TestMolesUseSelector(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.Config.Entropy = 0
+	g.Config.Selection = "round-robin"
+	g.Init(4, 2)
+	assert.Equal(t, 1, g.MoleFactory.MoleSet.Housed[1].HoleOccupied.ID)
+	assert.Equal(t, 2, g.MoleFactory.MoleSet.Housed[2].HoleOccupied.ID)
+
+	m := g.MoleFactory.MoleSet.Housed[1]
+	m.Tunnel(&g.HoleFactory.HoleSet)
+	assert.Equal(t, 3, m.HoleOccupied.ID)
+}
+
+func TestHolesListedInOrder(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.Config.Entropy = 0
+	g.Init(12, 3)
+	g.CollapseHole(g.HoleFactory.HoleSet.GetHole(2), 3)
+	buf.Reset()
+	g.ProcessPlayerInput("holes")
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "> "), "\n")
+	require.Len(t, lines, 13)
+	assert.Equal(t, "hole: 1 (open)", lines[0])
+	assert.Equal(t, "hole: 2 (collapsed)", lines[1])
+	assert.Equal(t, "hole: 10 (open)", lines[9])
+	assert.Equal(t, "hole: 12 (open)", lines[11])
+	assert.True(t, strings.HasPrefix(g.HoleFactory.HoleSet.PrintHolesString(), "hole: 1\nhole: 2\nhole: 3\n"))
+}
+
+func TestParseWeights(t *testing.T) {
+	w, err := ParseWeights("1:3, 4:0.5")
+	require.NoError(t, err)
+	assert.Equal(t, map[int]float64{1: 3, 4: 0.5}, w)
+
+	for _, bad := range []string{"1", "x:2", "2:-1", "0:1", "1:NaN", "1:Inf", "1:+inf"} {
+		_, err := ParseWeights(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestSelectFlags(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-select", "psychic"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr.String(), `unknown hole selection "psychic"`)
+
+	stderr.Reset()
+	code = run([]string{"-select", "weighted", "-weights", "nope"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr.String(), `bad hole weight "nope"`)
+}
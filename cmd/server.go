@@ -0,0 +1,524 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// netPlayer is one connection to a Server.  Each player has their own
+// hammers, combo and record; everything else about the board is shared.
+type netPlayer struct {
+	Name   string
+	Armory *Armory
+	Combo  Combo
+	Stats  Stats
+	Kills  []int
+
+	spectator bool
+	conn      net.Conn
+	out       chan string
+	renderer  *TextRenderer
+	gone      bool
+}
+
+// playerWriter hands text to the player's writer goroutine.  A player who
+// can't keep up is dropped rather than holding up the game.
+type playerWriter struct {
+	p *netPlayer
+}
+
+func (w playerWriter) Write(b []byte) (int, error) {
+	if w.p.gone {
+		return len(b), nil
+	}
+	select {
+	case w.p.out <- string(b):
+	default:
+		w.p.gone = true
+	}
+	return len(b), nil
+}
+
+type playerLine struct {
+	p    *netPlayer
+	line string
+}
+
+type pendingWhack struct {
+	p    *netPlayer
+	line string
+	hole int
+}
+
+// Server runs one Game for any number of players connected over TCP.  A
+// single loop owns the game: connections only pass lines to it, so game
+// code never runs on two goroutines at once.  Events go to every player and
+// responses to the player whose command caused them.
+//
+// Whacks are not carried out the moment they arrive.  They are held for
+// Window, and whacks on the same hole within that window are carried out in
+// a random order, so the player with the quicker connection doesn't always
+// win.  Whoever gets the mole has it, the others are told they were beaten
+// to it and the swing doesn't count against them.
+//
+// Spectators see what the players see, Delay late.  Everyone is talked to
+// in the language of Catalog.
+type Server struct {
+	Game    *Game
+	Window  time.Duration
+	Delay   time.Duration
+	Catalog *Catalog
+
+	players    []*netPlayer
+	spectators []*netPlayer
+	current    *netPlayer
+	molePlay   *netPlayer
+	pending    []pendingWhack
+	delayed    []delayedRecord
+	nextID     int
+	lines      chan playerLine
+	joins      chan *netPlayer
+	watchers   chan *netPlayer
+	leaves     chan *netPlayer
+	done       chan struct{}
+	watching   net.Listener
+}
+
+func NewServer(g *Game, window time.Duration) *Server {
+	s := &Server{
+		Game:     g,
+		Window:   window,
+		lines:    make(chan playerLine),
+		joins:    make(chan *netPlayer),
+		watchers: make(chan *netPlayer),
+		leaves:   make(chan *netPlayer),
+		done:     make(chan struct{}),
+	}
+	g.Renderer = serverRenderer{s}
+	return s
+}
+
+type serverRenderer struct {
+	s *Server
+}
+
+func (r serverRenderer) Render(rec Record) {
+	if rec.Type == ResponseRecord && r.s.current != nil {
+		r.s.current.renderer.Render(rec)
+		return
+	}
+	for _, p := range r.s.players {
+		p.renderer.Render(rec)
+	}
+	r.s.spectate(rec)
+}
+
+func (r serverRenderer) Prompt() {
+	if r.s.current != nil {
+		r.s.current.renderer.Prompt()
+	}
+}
+
+// Serve accepts players on ln and runs the game until it ends.  The
+// listener is closed on the way out.
+func (s *Server) Serve(ln net.Listener) {
+	go s.accept(ln, s.joins)
+	g := s.Game
+	g.State = Playing
+	tick := time.NewTicker(g.Config.Tick)
+	defer tick.Stop()
+	var window, release <-chan time.Time
+	for g.State != End {
+		ticks := tick.C
+		if g.Paused {
+			ticks = nil
+		}
+		if release == nil && len(s.delayed) > 0 {
+			release = time.After(time.Until(s.delayed[0].due))
+		}
+		select {
+		case <-ticks:
+			g.ProcessTick()
+			s.spectateBoard()
+			for _, p := range s.players {
+				p.renderer.Prompt()
+			}
+		case p := <-s.joins:
+			s.join(p)
+		case p := <-s.watchers:
+			s.watch(p)
+		case <-release:
+			release = nil
+			s.release(time.Now())
+		case p := <-s.leaves:
+			s.leave(p)
+		case l := <-s.lines:
+			paused := g.Paused
+			s.handle(l.p, l.line)
+			if paused && !g.Paused {
+				tick.Reset(g.Config.Tick)
+			}
+			if len(s.pending) > 0 && window == nil {
+				window = time.After(s.Window)
+			}
+		case <-window:
+			window = nil
+			s.resolve()
+			s.spectateBoard()
+		}
+		s.dropGone()
+	}
+	s.record(nil, "net.scores", nil, s.scores())
+	// The game is over, so there's nothing left to hold back.
+	s.release(time.Now().Add(s.Delay))
+	close(s.done)
+	ln.Close()
+	if s.watching != nil {
+		s.watching.Close()
+	}
+	for _, p := range append(s.players, s.spectators...) {
+		close(p.out)
+	}
+}
+
+func (s *Server) accept(ln net.Listener, joins chan *netPlayer) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		p := &netPlayer{conn: conn, out: make(chan string, 256)}
+		p.renderer = &TextRenderer{Out: playerWriter{p}, Catalog: s.Catalog}
+		go s.write(p)
+		select {
+		case joins <- p:
+			go s.read(p)
+		case <-s.done:
+			close(p.out)
+			return
+		}
+	}
+}
+
+func (s *Server) read(p *netPlayer) {
+	scanner := bufio.NewScanner(p.conn)
+	for scanner.Scan() {
+		select {
+		case s.lines <- playerLine{p, scanner.Text()}:
+		case <-s.done:
+			return
+		}
+	}
+	select {
+	case s.leaves <- p:
+	case <-s.done:
+	}
+}
+
+func (s *Server) write(p *netPlayer) {
+	for text := range p.out {
+		if _, err := io.WriteString(p.conn, text); err != nil {
+			break
+		}
+	}
+	p.conn.Close()
+	for range p.out {
+	}
+}
+
+// whackerOnly are the commands the mole player can't use against their own
+// moles.
+var whackerOnly = map[string]bool{"whack": true, "peek": true, "trap": true, "bait": true, "smoke": true, "plug": true, "unplug": true}
+
+// record renders a message of the server's own, to p alone or to everyone
+// when p is nil.
+func (s *Server) record(p *netPlayer, key string, f Fields, items []Fields) {
+	rec := Record{Type: EventRecord, Key: key, Tick: s.Game.Ticks, At: s.Game.Elapsed(), Fields: f, Items: items}
+	if p != nil {
+		rec.Type = ResponseRecord
+		p.renderer.Render(rec)
+		return
+	}
+	for _, q := range s.players {
+		q.renderer.Render(rec)
+	}
+	s.spectate(rec)
+}
+
+func (s *Server) broadcastExcept(p *netPlayer, key string, f Fields) {
+	rec := Record{Type: EventRecord, Key: key, Tick: s.Game.Ticks, At: s.Game.Elapsed(), Fields: f}
+	for _, q := range s.players {
+		if q != p {
+			q.renderer.Render(rec)
+		}
+	}
+	s.spectate(rec)
+}
+
+func (s *Server) join(p *netPlayer) {
+	s.nextID++
+	p.Name = fmt.Sprintf("player%d", s.nextID)
+	p.Armory = NewArmory(s.Game.Config.Hammers)
+	s.broadcastExcept(nil, "net.joined", Fields{"player": p.Name})
+	s.players = append(s.players, p)
+	s.record(p, "game.welcome", nil, nil)
+	s.record(p, "net.welcome", Fields{"player": p.Name}, nil)
+	if len(s.spectators) > 0 {
+		s.record(p, "net.spectators", Fields{"count": len(s.spectators)}, nil)
+	}
+	p.renderer.Prompt()
+}
+
+func (s *Server) leave(p *netPlayer) {
+	if p.spectator {
+		s.unwatch(p)
+		return
+	}
+	for i, q := range s.players {
+		if q == p {
+			s.players = append(s.players[:i], s.players[i+1:]...)
+			p.gone = true
+			close(p.out)
+			if s.molePlay == p {
+				s.molePlay = nil
+			}
+			s.broadcastExcept(nil, "net.left", Fields{"player": p.Name})
+			return
+		}
+	}
+}
+
+func (s *Server) dropGone() {
+	for _, p := range append(append([]*netPlayer(nil), s.players...), s.spectators...) {
+		if p.gone {
+			s.leave(p)
+		}
+	}
+}
+
+// as runs fn with p's hammers and combo swapped into the game, crediting
+// p with any whacks and kills it makes.
+func (s *Server) as(p *netPlayer, fn func()) {
+	g := s.Game
+	before := g.Stats
+	dead := make(map[int]bool, len(g.MoleFactory.MoleSet.Dead))
+	for id := range g.MoleFactory.MoleSet.Dead {
+		dead[id] = true
+	}
+	g.Armory, g.Combo = p.Armory, p.Combo
+	s.current = p
+	fn()
+	s.current = nil
+	p.Combo = g.Combo
+	p.Stats.Whacks += g.Stats.Whacks - before.Whacks
+	p.Stats.Hits += g.Stats.Hits - before.Hits
+	p.Stats.Misses += g.Stats.Misses - before.Misses
+	p.Stats.Whiffs += g.Stats.Whiffs - before.Whiffs
+	p.Stats.Deflected += g.Stats.Deflected - before.Deflected
+	for _, m := range sortedMoles(g.MoleFactory.MoleSet.Dead) {
+		if !dead[m.ID] {
+			p.Kills = append(p.Kills, m.ID)
+		}
+	}
+}
+
+func (s *Server) handle(p *netPlayer, line string) {
+	if p.gone {
+		return
+	}
+	if p.spectator {
+		s.record(p, "spectate.read_only", nil, nil)
+		return
+	}
+	parts := strings.Fields(line)
+	if len(parts) == 0 {
+		p.renderer.Prompt()
+		return
+	}
+	// Whacks are grouped by the hole they hit, so "whack 2" and "whack 02"
+	// contend for the same mole.  Anything that isn't a hole number is left
+	// for the game to complain about.
+	whackAt := 0
+	if parts[0] == "whack" && len(parts) > 1 {
+		whackAt, _ = strconv.Atoi(parts[1])
+	}
+	switch {
+	case parts[0] == "name":
+		s.rename(p, parts[1:])
+	case parts[0] == "scores":
+		s.record(p, "net.scores", nil, s.scores())
+	case parts[0] == "quit":
+		s.record(p, "net.bye", Fields{"player": p.Name}, nil)
+		p.gone = true
+		return
+	case parts[0] == "m" && s.Game.Config.Versus > 0:
+		// Quitting the moles ends the shared game in a local versus game,
+		// here it only hands the moles back.
+		quit := len(parts) > 1 && parts[1] == "quit"
+		switch {
+		case s.molePlay == p && quit:
+			s.molePlay = nil
+			s.broadcastExcept(nil, "net.mole_released", Fields{"player": p.Name})
+		case s.molePlay == nil && quit:
+			s.record(p, "net.not_mole_player", nil, nil)
+		case s.molePlay != nil && s.molePlay != p:
+			s.record(p, "net.mole_taken", Fields{"player": s.molePlay.Name}, nil)
+		default:
+			if s.molePlay == nil {
+				s.molePlay = p
+				s.broadcastExcept(nil, "net.mole_player", Fields{"player": p.Name})
+			}
+			s.as(p, func() { s.Game.ProcessPlayerInput(line) })
+			return
+		}
+	case p == s.molePlay && whackerOnly[parts[0]]:
+		s.record(p, "net.mole_only", nil, nil)
+	case parts[0] == "pause" || parts[0] == "resume":
+		s.record(p, "net.no_pause", nil, nil)
+	case whackAt > 0 && !s.Game.Paused:
+		s.pending = append(s.pending, pendingWhack{p: p, line: line, hole: whackAt})
+		return
+	default:
+		s.as(p, func() { s.Game.ProcessPlayerInput(line) })
+		return
+	}
+	p.renderer.Prompt()
+}
+
+func (s *Server) rename(p *netPlayer, args []string) {
+	if len(args) == 0 {
+		s.record(p, "net.no_name", nil, nil)
+		return
+	}
+	for _, q := range s.players {
+		if q.Name == args[0] {
+			s.record(p, "net.name_taken", Fields{"player": args[0]}, nil)
+			return
+		}
+	}
+	old := p.Name
+	p.Name = args[0]
+	s.record(nil, "net.renamed", Fields{"old": old, "player": p.Name}, nil)
+}
+
+// resolve carries out the whacks held during the last window, hole by hole
+// in the order the holes were first whacked.
+func (s *Server) resolve() {
+	g := s.Game
+	var holes []int
+	groups := make(map[int][]pendingWhack)
+	for _, w := range s.pending {
+		if w.p.gone {
+			continue
+		}
+		if _, ok := groups[w.hole]; !ok {
+			holes = append(holes, w.hole)
+		}
+		groups[w.hole] = append(groups[w.hole], w)
+	}
+	s.pending = nil
+	for _, hole := range holes {
+		group := groups[hole]
+		g.Rand.Shuffle(len(group), func(i, j int) { group[i], group[j] = group[j], group[i] })
+		var winner *netPlayer
+		for _, w := range group {
+			if g.State == End {
+				return
+			}
+			if winner != nil && winner != w.p {
+				s.record(w.p, "net.beaten", Fields{"player": winner.Name, "hole": hole}, nil)
+				w.p.renderer.Prompt()
+				continue
+			}
+			kills := len(w.p.Kills)
+			s.as(w.p, func() { g.ProcessPlayerInput(w.line) })
+			for _, id := range w.p.Kills[kills:] {
+				winner = w.p
+				s.broadcastExcept(w.p, "net.kill", Fields{"player": w.p.Name, "mole": id, "hole": hole})
+			}
+		}
+	}
+}
+
+func (s *Server) scores() []Fields {
+	players := append([]*netPlayer(nil), s.players...)
+	sort.SliceStable(players, func(i, j int) bool { return players[i].Combo.Score > players[j].Combo.Score })
+	var items []Fields
+	for _, p := range players {
+		items = append(items, Fields{
+			"player":   p.Name,
+			"score":    p.Combo.Score,
+			"kills":    len(p.Kills),
+			"hits":     p.Stats.Hits,
+			"whacks":   p.Stats.Whacks,
+			"accuracy": 100 * p.Stats.Accuracy(),
+		})
+	}
+	return items
+}
+
+func runServe(args []string, stdout io.Writer, stderr io.Writer) int {
+	fs := flag.NewFlagSet("wam serve", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	addr := fs.String("addr", ":7070", "address to listen on")
+	holes := fs.Int("holes", 9, "number of holes on the board")
+	moles := fs.Int("moles", 9, "number of moles to whack")
+	entropy := fs.Int("entropy", 30, "percent chance per tick that a mole moves or changes exposure")
+	tick := fs.Duration("tick", time.Second, "time between mole moves")
+	timeLimit := fs.Duration("time-limit", 0, "lose if the moles are still alive after this long (0 for no limit)")
+	window := fs.Duration("window", 100*time.Millisecond, "whacks on the same hole this close together count as simultaneous")
+	spectateAddr := fs.String("spectate-addr", "", "address spectators connect to (empty for no spectators)")
+	delay := fs.Duration("spectate-delay", 10*time.Second, "how far behind the game spectators are kept")
+	versus := fs.Int("versus", 0, "hand this many moles to the first player who types an \"m \" command")
+	seed := fs.Int64("seed", 0, "random seed (defaults to the time)")
+	lang := fs.String("lang", "en", "language to talk to players in: "+strings.Join(Languages(), ", "))
+	if err := fs.Parse(args); err != nil {
+		return ExitError
+	}
+	catalog, err := NewCatalog(*lang)
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return ExitError
+	}
+	if *tick <= 0 || *window < 0 || *delay < 0 {
+		fmt.Fprintf(stderr, "tick must be positive and window and spectate-delay can't be negative\n")
+		return ExitError
+	}
+	g := NewGame(io.Discard)
+	if *seed != 0 {
+		g.SetSeed(*seed)
+	}
+	g.Config.Entropy = *entropy
+	g.Config.Tick = *tick
+	g.Config.TimeLimit = *timeLimit
+	g.Config.Versus = *versus
+	g.Init(*holes, *moles)
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return ExitError
+	}
+	fmt.Fprintf(stdout, "listening on %s\n", ln.Addr())
+	s := NewServer(g, *window)
+	s.Delay = *delay
+	s.Catalog = catalog
+	if *spectateAddr != "" {
+		sl, err := net.Listen("tcp", *spectateAddr)
+		if err != nil {
+			ln.Close()
+			fmt.Fprintf(stderr, "%v\n", err)
+			return ExitError
+		}
+		fmt.Fprintf(stdout, "spectators on %s\n", sl.Addr())
+		s.Spectate(sl)
+	}
+	s.Serve(ln)
+	return g.ExitCode()
+}
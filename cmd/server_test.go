@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func startServer(t *testing.T, holes int, window time.Duration, setup ...func(*Server)) (*Server, string, chan struct{}) {
+	t.Helper()
+	g := NewGame(io.Discard)
+	g.Config.Entropy = 0
+	g.Config.Tick = time.Hour
+	g.Config.Hammers = []Hammer{{Name: "mallet"}}
+	g.Init(holes, holes)
+	for _, m := range g.MoleFactory.MoleSet.Housed {
+		m.ToggleState()
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := NewServer(g, window)
+	for _, f := range setup {
+		f(s)
+	}
+	finished := make(chan struct{})
+	go func() {
+		s.Serve(ln)
+		close(finished)
+	}()
+	return s, ln.Addr().String(), finished
+}
+
+type testClient struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+	seen strings.Builder
+}
+
+func dial(t *testing.T, addr string) *testClient {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	c := &testClient{t: t, conn: conn, r: bufio.NewReader(conn)}
+	c.waitFor("You are ")
+	return c
+}
+
+func (c *testClient) send(line string) {
+	fmt.Fprintln(c.conn, line)
+}
+
+// waitFor reads until the output so far contains one of wants and returns
+// the output.
+func (c *testClient) waitFor(wants ...string) string {
+	c.t.Helper()
+	c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 1024)
+	for {
+		for _, want := range wants {
+			if strings.Contains(c.seen.String(), want) {
+				return c.seen.String()
+			}
+		}
+		n, err := c.r.Read(buf)
+		c.seen.Write(buf[:n])
+		if err != nil {
+			c.t.Fatalf("waiting for %q: %v\ngot %q", wants, err, c.seen.String())
+		}
+	}
+}
+
+func TestServerJoinAndRename(t *testing.T) {
+	_, addr, _ := startServer(t, 3, 10*time.Millisecond)
+	a := dial(t, addr)
+	b := dial(t, addr)
+	a.waitFor("player2 joined the game.")
+	b.send("name bob")
+	a.waitFor("player2 is now bob.")
+	a.send("name bob")
+	a.waitFor("Someone is already called bob.")
+	b.send("quit")
+	b.waitFor("Bye bob!")
+	a.waitFor("bob left the game.")
+}
+
+func TestServerResponsesArePrivate(t *testing.T) {
+	_, addr, _ := startServer(t, 3, 10*time.Millisecond)
+	a := dial(t, addr)
+	b := dial(t, addr)
+	a.waitFor("player2 joined")
+	a.send("holes")
+	a.waitFor("hole: 3")
+	b.send("name bob")
+	a.waitFor("player2 is now bob.")
+	b.waitFor("player2 is now bob.")
+	assert.NotContains(t, b.seen.String(), "hole: 3")
+}
+
+func TestServerSimultaneousWhacks(t *testing.T) {
+	s, addr, finished := startServer(t, 2, 200*time.Millisecond)
+	a := dial(t, addr)
+	b := dial(t, addr)
+	a.waitFor("player2 joined")
+	a.send("whack 1")
+	b.send("whack 1")
+	a.waitFor("bonked out of existence!", "Too slow, ")
+	b.waitFor("bonked out of existence!", "Too slow, ")
+
+	// Exactly one of them gets the mole and the other is told so.
+	all := a.seen.String() + b.seen.String()
+	assert.Equal(t, 1, strings.Count(all, "bonked out of existence!"))
+	assert.Equal(t, 1, strings.Count(all, "Too slow, "))
+	assert.Equal(t, 1, strings.Count(all, " whacked mole 1 in hole 1!"))
+
+	a.send("whack 2")
+	a.waitFor("Scores:")
+	b.waitFor("Scores:")
+	<-finished
+	assert.Equal(t, Won, s.Game.Outcome)
+	var kills, whacks int
+	for _, p := range s.players {
+		kills += len(p.Kills)
+		whacks += p.Stats.Whacks
+	}
+	assert.Equal(t, 2, kills)
+	assert.Equal(t, 2, whacks)
+}
+
+func TestServerDisconnect(t *testing.T) {
+	_, addr, _ := startServer(t, 3, 10*time.Millisecond)
+	a := dial(t, addr)
+	b := dial(t, addr)
+	a.waitFor("player2 joined")
+	b.conn.Close()
+	a.waitFor("player2 left the game.")
+	a.send("scores")
+	out := a.waitFor("player1: ")
+	assert.NotContains(t, out[strings.LastIndex(out, "Scores:"):], "player2")
+}
+
+func TestServerWhacksGroupedByHole(t *testing.T) {
+	_, addr, _ := startServer(t, 3, 200*time.Millisecond)
+	a := dial(t, addr)
+	b := dial(t, addr)
+	a.waitFor("player2 joined")
+	a.send("whack 1")
+	b.send("whack 01")
+	a.waitFor("bonked out of existence!", "Too slow, ")
+	b.waitFor("bonked out of existence!", "Too slow, ")
+	all := a.seen.String() + b.seen.String()
+	assert.Equal(t, 1, strings.Count(all, "bonked out of existence!"))
+	assert.Equal(t, 1, strings.Count(all, "Too slow, "))
+}
+
+func TestServerNoPausing(t *testing.T) {
+	_, addr, _ := startServer(t, 3, 10*time.Millisecond)
+	a := dial(t, addr)
+	a.send("pause")
+	a.waitFor("The game can't be paused with other people playing.")
+	a.send("whack 1")
+	a.waitFor("bonked out of existence!")
+}
+
+func TestServerMolePlayerQuits(t *testing.T) {
+	_, addr, _ := startServer(t, 3, 10*time.Millisecond, func(s *Server) {
+		s.Game.Config.Versus = 1
+		s.Game.ControlMoles(1)
+	})
+	a := dial(t, addr)
+	b := dial(t, addr)
+	a.waitFor("player2 joined")
+
+	// Quitting moles you don't have doesn't take them first.
+	b.send("m quit")
+	b.waitFor("You aren't playing the moles.")
+	a.send("m moles")
+	b.waitFor("player1 is playing the moles.")
+	b.send("m quit")
+	b.waitFor("player1 is already playing the moles.")
+
+	// The mole player can't set traps for their own moles.
+	a.send("trap 1")
+	a.waitFor("leave the whacking and trapping to the others.")
+	a.send("m quit")
+	b.waitFor("player1 stops playing the moles.")
+
+	// The game carries on and someone else can take the moles.
+	b.send("m moles")
+	a.waitFor("player2 is playing the moles.")
+	a.send("scores")
+	a.waitFor("Scores:")
+}
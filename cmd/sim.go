@@ -0,0 +1,351 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type SimConfig struct {
+	Holes   int           `json:"holes"`
+	Moles   int           `json:"moles"`
+	Entropy int           `json:"entropy"`
+	Tick    time.Duration `json:"tick"`
+	Think   time.Duration `json:"think"`
+	MaxTime time.Duration `json:"max_time"`
+	Bot     string        `json:"bot"`
+}
+
+type GameResult struct {
+	Won      bool
+	Time     time.Duration
+	Stats    Stats
+	Survival []time.Duration
+	Alive    int
+}
+
+type Distribution struct {
+	N    int     `json:"n"`
+	Mean float64 `json:"mean"`
+	Min  float64 `json:"min"`
+	P50  float64 `json:"p50"`
+	P90  float64 `json:"p90"`
+	Max  float64 `json:"max"`
+}
+
+type SimReport struct {
+	Config    SimConfig    `json:"config"`
+	Games     int          `json:"games"`
+	Wins      int          `json:"wins"`
+	TimeToWin Distribution `json:"time_to_win"`
+	Accuracy  Distribution `json:"accuracy"`
+	Misses    Distribution `json:"misses"`
+	Survival  Distribution `json:"survival"`
+	Survivors Distribution `json:"survivors"`
+}
+
+func summarize(xs []float64) Distribution {
+	if len(xs) == 0 {
+		return Distribution{}
+	}
+	sorted := append([]float64(nil), xs...)
+	sort.Float64s(sorted)
+	sum := 0.0
+	for _, x := range sorted {
+		sum += x
+	}
+	pct := func(p float64) float64 {
+		return sorted[int(math.Ceil(p*float64(len(sorted))))-1]
+	}
+	return Distribution{
+		N:    len(sorted),
+		Mean: sum / float64(len(sorted)),
+		Min:  sorted[0],
+		P50:  pct(0.5),
+		P90:  pct(0.9),
+		Max:  sorted[len(sorted)-1],
+	}
+}
+
+// SimulateGame plays one headless game with a bot on a manual clock.  Moles
+// tick every cfg.Tick and the bot gets a turn every cfg.Think; on a tie the
+// moles move first.  The result's Survival has how long each mole lasted,
+// with the moles still alive at the end counted as lasting the whole game.
+func SimulateGame(cfg SimConfig, seed int64) (GameResult, error) {
+	if cfg.Tick <= 0 || cfg.Think <= 0 || cfg.MaxTime <= 0 {
+		return GameResult{}, fmt.Errorf("tick, think and max time must be positive")
+	}
+	g := NewGame(io.Discard)
+	g.Renderer = QuietRenderer{}
+	clock := NewManualClock(time.Unix(0, 0))
+	g.Clock = clock
+	g.SetSeed(seed)
+	g.Config.Entropy = cfg.Entropy
+	g.Config.Tick = cfg.Tick
+	g.Config.TimeLimit = cfg.MaxTime
+	bot, err := NewBot(cfg.Bot, rand.New(rand.NewSource(^seed)))
+	if err != nil {
+		return GameResult{}, err
+	}
+	g.Init(cfg.Holes, cfg.Moles)
+	g.State = Playing
+
+	deaths := make(map[int]time.Duration)
+	nextTick, nextThink := cfg.Tick, cfg.Think
+	for g.State != End {
+		if nextThink < nextTick {
+			clock.Set(g.StartTime.Add(nextThink))
+			nextThink += cfg.Think
+			if cmd := bot.Act(g.Observe()); cmd != "" {
+				g.ProcessPlayerInput(cmd)
+			}
+			for id := range g.MoleFactory.MoleSet.Dead {
+				if _, ok := deaths[id]; !ok {
+					deaths[id] = g.Elapsed()
+				}
+			}
+			continue
+		}
+		clock.Set(g.StartTime.Add(nextTick))
+		nextTick += g.Config.Tick
+		g.ProcessTick()
+	}
+
+	res := GameResult{Won: g.Outcome == Won, Time: g.Elapsed(), Stats: g.Stats}
+	for _, m := range g.MoleFactory.MoleSet.Index.Moles {
+		d, ok := deaths[m.ID]
+		if !ok {
+			d = g.Elapsed()
+		}
+		res.Survival = append(res.Survival, d)
+	}
+	sort.Slice(res.Survival, func(i, j int) bool { return res.Survival[i] < res.Survival[j] })
+	res.Alive = g.WinCondition - len(deaths)
+	return res, nil
+}
+
+func Aggregate(cfg SimConfig, results []GameResult) SimReport {
+	r := SimReport{Config: cfg, Games: len(results)}
+	var ttw, acc, misses, survival, survivors []float64
+	for _, res := range results {
+		if res.Won {
+			r.Wins++
+			ttw = append(ttw, res.Time.Seconds())
+		}
+		acc = append(acc, res.Stats.Accuracy())
+		misses = append(misses, float64(res.Stats.Misses+res.Stats.Whiffs))
+		for _, d := range res.Survival {
+			survival = append(survival, d.Seconds())
+		}
+		survivors = append(survivors, float64(res.Alive))
+	}
+	r.TimeToWin = summarize(ttw)
+	r.Accuracy = summarize(acc)
+	r.Misses = summarize(misses)
+	r.Survival = summarize(survival)
+	r.Survivors = summarize(survivors)
+	return r
+}
+
+// RunSimulation plays games seeded seed, seed+1, ... for every config across
+// a pool of workers.  Each config sees the same seeds so configs can be
+// compared game for game.
+func RunSimulation(cfgs []SimConfig, games int, seed int64, workers int) ([]SimReport, error) {
+	type job struct{ cfg, game int }
+	results := make([][]GameResult, len(cfgs))
+	for i := range results {
+		results[i] = make([]GameResult, games)
+	}
+	jobs := make(chan job)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+	for range max(workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				res, err := SimulateGame(cfgs[j.cfg], seed+int64(j.game))
+				if err != nil {
+					mu.Lock()
+					firstErr = err
+					mu.Unlock()
+				}
+				results[j.cfg][j.game] = res
+			}
+		}()
+	}
+	for c := range cfgs {
+		for n := range games {
+			jobs <- job{c, n}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	reports := make([]SimReport, len(cfgs))
+	for i, cfg := range cfgs {
+		reports[i] = Aggregate(cfg, results[i])
+	}
+	return reports, nil
+}
+
+func WriteReportsJSON(w io.Writer, reports []SimReport) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(reports)
+}
+
+func WriteReportsCSV(w io.Writer, reports []SimReport) error {
+	cw := csv.NewWriter(w)
+	header := []string{"holes", "moles", "entropy", "tick", "think", "bot", "games", "wins"}
+	dists := []string{"time_to_win", "accuracy", "misses", "survival", "survivors"}
+	for _, d := range dists {
+		for _, col := range []string{"n", "mean", "min", "p50", "p90", "max"} {
+			header = append(header, d+"_"+col)
+		}
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	f := func(x float64) string {
+		return strconv.FormatFloat(x, 'f', 4, 64)
+	}
+	for _, r := range reports {
+		row := []string{
+			strconv.Itoa(r.Config.Holes), strconv.Itoa(r.Config.Moles), strconv.Itoa(r.Config.Entropy),
+			r.Config.Tick.String(), r.Config.Think.String(), r.Config.Bot,
+			strconv.Itoa(r.Games), strconv.Itoa(r.Wins),
+		}
+		for _, d := range []Distribution{r.TimeToWin, r.Accuracy, r.Misses, r.Survival, r.Survivors} {
+			row = append(row, strconv.Itoa(d.N), f(d.Mean), f(d.Min), f(d.P50), f(d.P90), f(d.Max))
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func parseInts(s string) ([]int, error) {
+	var out []int
+	for _, part := range strings.Split(s, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil, fmt.Errorf("bad number %q", part)
+		}
+		out = append(out, n)
+	}
+	return out, nil
+}
+
+func parseDurations(s string) ([]time.Duration, error) {
+	var out []time.Duration
+	for _, part := range strings.Split(s, ",") {
+		d, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("bad duration %q", part)
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+// runSim implements the "sim" subcommand.  Every comma separated list of
+// values is expanded so each combination becomes its own configuration.
+func runSim(args []string, stdout io.Writer, stderr io.Writer) int {
+	fs := flag.NewFlagSet("wam sim", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	holes := fs.String("holes", "3", "comma separated board sizes")
+	moles := fs.String("moles", "3", "comma separated mole counts")
+	entropy := fs.String("entropy", "30", "comma separated entropy values")
+	tick := fs.String("tick", "1s", "comma separated tick rates")
+	think := fs.Duration("think", 500*time.Millisecond, "time between bot actions")
+	maxTime := fs.Duration("max-time", 5*time.Minute, "time after which a game counts as lost")
+	bot := fs.String("bot", "greedy", "bot player: idle, random, greedy or sloppy")
+	games := fs.Int("games", 100, "games per configuration")
+	seed := fs.Int64("seed", 1, "seed of the first game")
+	workers := fs.Int("workers", runtime.NumCPU(), "games to run in parallel")
+	format := fs.String("format", "csv", "report format: csv or json")
+	if err := fs.Parse(args); err != nil {
+		return ExitError
+	}
+	if *think <= 0 || *maxTime <= 0 || *games <= 0 {
+		fmt.Fprintf(stderr, "think, max-time and games must be positive\n")
+		return ExitError
+	}
+	if _, err := NewBot(*bot, nil); err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return ExitError
+	}
+
+	cfgs, err := expandSimConfigs(*holes, *moles, *entropy, *tick, SimConfig{Think: *think, MaxTime: *maxTime, Bot: *bot})
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return ExitError
+	}
+	reports, err := RunSimulation(cfgs, *games, *seed, *workers)
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return ExitError
+	}
+	switch *format {
+	case "json":
+		err = WriteReportsJSON(stdout, reports)
+	case "csv":
+		err = WriteReportsCSV(stdout, reports)
+	default:
+		err = fmt.Errorf("unknown format %q", *format)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "%v\n", err)
+		return ExitError
+	}
+	return 0
+}
+
+func expandSimConfigs(holes, moles, entropy, tick string, base SimConfig) ([]SimConfig, error) {
+	holeCounts, err := parseInts(holes)
+	if err != nil {
+		return nil, err
+	}
+	moleCounts, err := parseInts(moles)
+	if err != nil {
+		return nil, err
+	}
+	entropies, err := parseInts(entropy)
+	if err != nil {
+		return nil, err
+	}
+	ticks, err := parseDurations(tick)
+	if err != nil {
+		return nil, err
+	}
+	var cfgs []SimConfig
+	for _, h := range holeCounts {
+		for _, m := range moleCounts {
+			for _, e := range entropies {
+				for _, t := range ticks {
+					cfg := base
+					cfg.Holes, cfg.Moles, cfg.Entropy, cfg.Tick = h, m, e, t
+					cfgs = append(cfgs, cfg)
+				}
+			}
+		}
+	}
+	return cfgs, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserve(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.Init(4, 2)
+	g.MoleFactory.MoleSet.Housed[2].ToggleState()
+	obs := g.Observe()
+	assert.Equal(t, []HoleView{{ID: 1}, {ID: 2, Occupied: true, Exposed: true}, {ID: 3}, {ID: 4}}, obs.Holes)
+	assert.Equal(t, []int{2}, obs.ExposedHoles())
+	assert.Equal(t, 2, obs.MolesLeft)
+
+	bot, err := NewBot("greedy", rand.New(rand.NewSource(1)))
+	require.NoError(t, err)
+	assert.Equal(t, "whack 2", bot.Act(obs))
+	_, err = NewBot("psychic", nil)
+	require.Error(t, err)
+}
+
+func TestSimulateGame(t *testing.T) {
+	cfg := SimConfig{Holes: 5, Moles: 3, Entropy: 50, Tick: time.Second, Think: 300 * time.Millisecond, MaxTime: time.Minute, Bot: "greedy"}
+	a, err := SimulateGame(cfg, 7)
+	require.NoError(t, err)
+	b, err := SimulateGame(cfg, 7)
+	require.NoError(t, err)
+	assert.Equal(t, a, b)
+	assert.True(t, a.Won)
+	assert.Len(t, a.Survival, 3)
+	assert.Equal(t, 1.0, a.Stats.Accuracy())
+
+	cfg.Bot = "idle"
+	c, err := SimulateGame(cfg, 7)
+	require.NoError(t, err)
+	assert.False(t, c.Won)
+	assert.Equal(t, time.Minute, c.Time)
+	assert.Equal(t, 3, c.Alive)
+	assert.Equal(t, []time.Duration{time.Minute, time.Minute, time.Minute}, c.Survival, "moles that outlast the game count at its end")
+
+	cfg.Think = 0
+	_, err = SimulateGame(cfg, 7)
+	assert.Error(t, err)
+}
+
+func TestRunSimulation(t *testing.T) {
+	cfgs, err := expandSimConfigs("3,6", "3", "20,60", "1s", SimConfig{Think: time.Second, MaxTime: time.Minute, Bot: "sloppy"})
+	require.NoError(t, err)
+	require.Len(t, cfgs, 4)
+
+	serial, err := RunSimulation(cfgs, 20, 1, 1)
+	require.NoError(t, err)
+	parallel, err := RunSimulation(cfgs, 20, 1, 4)
+	require.NoError(t, err)
+	assert.Equal(t, serial, parallel)
+	assert.Equal(t, 20, serial[0].Games)
+
+	assert.Equal(t, Distribution{N: 4, Mean: 2.5, Min: 1, P50: 2, P90: 4, Max: 4}, summarize([]float64{4, 1, 3, 2}))
+}
+
+func TestRunSimCommand(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"sim", "-games", "5", "-holes", "3,4", "-entropy", "40"}, strings.NewReader(""), &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	rows, err := csv.NewReader(&stdout).ReadAll()
+	require.NoError(t, err)
+	assert.Len(t, rows, 3)
+	assert.Equal(t, "holes", rows[0][0])
+
+	stdout.Reset()
+	code = run([]string{"sim", "-games", "2", "-format", "json"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, 0, code)
+	assert.Contains(t, stdout.String(), `"time_to_win"`)
+
+	code = run([]string{"sim", "-bot", "psychic"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, ExitError, code)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"net"
+	"time"
+)
+
+// Spectators connect on a listener of their own.  They get the events,
+// kills and scores the players get, plus the board after every tick, but
+// only once Delay has passed so they can't call out where the moles are.
+// Anything they type is ignored.
+
+type delayedRecord struct {
+	due time.Time
+	rec Record
+}
+
+// Spectate accepts spectators on ln for as long as the game runs.  Call it
+// before Serve.
+func (s *Server) Spectate(ln net.Listener) {
+	s.watching = ln
+	go s.accept(ln, s.watchers)
+}
+
+// spectate queues rec for the spectators.
+func (s *Server) spectate(rec Record) {
+	if len(s.spectators) == 0 {
+		return
+	}
+	s.delayed = append(s.delayed, delayedRecord{due: time.Now().Add(s.Delay), rec: rec})
+	if s.Delay == 0 {
+		s.release(time.Now())
+	}
+}
+
+// release sends the spectators everything due by now.
+func (s *Server) release(now time.Time) {
+	n := 0
+	for ; n < len(s.delayed) && !s.delayed[n].due.After(now); n++ {
+		for _, p := range s.spectators {
+			p.renderer.Render(s.delayed[n].rec)
+		}
+	}
+	s.delayed = s.delayed[n:]
+}
+
+func (s *Server) spectateBoard() {
+	if len(s.spectators) == 0 {
+		return
+	}
+	var items []Fields
+	for _, h := range s.Game.HoleFactory.HoleSet.Index.Holes {
+		f := Fields{"hole": h.ID, "status": word(holeStatus(h))}
+		if m := h.OccupyingMole; m != nil && m.State != Dead {
+			f["status"] = Text{Key: "word.mole_status", Fields: Fields{"mole": m.ID, "status": f["status"]}}
+		}
+		items = append(items, f)
+	}
+	s.spectate(Record{Type: EventRecord, Key: "spectate.board", Tick: s.Game.Ticks, At: s.Game.Elapsed(), Items: items})
+}
+
+func (s *Server) watch(p *netPlayer) {
+	p.spectator = true
+	s.spectators = append(s.spectators, p)
+	s.record(p, "game.welcome", nil, nil)
+	s.record(p, "spectate.welcome", Fields{"delay": s.Delay, "players": len(s.players)}, nil)
+	s.announceSpectators()
+	s.spectateBoard()
+	s.spectate(Record{Type: EventRecord, Key: "net.scores", Tick: s.Game.Ticks, At: s.Game.Elapsed(), Items: s.scores()})
+}
+
+func (s *Server) unwatch(p *netPlayer) {
+	for i, q := range s.spectators {
+		if q == p {
+			s.spectators = append(s.spectators[:i], s.spectators[i+1:]...)
+			p.gone = true
+			close(p.out)
+			s.announceSpectators()
+			return
+		}
+	}
+}
+
+func (s *Server) announceSpectators() {
+	rec := Record{Type: EventRecord, Key: "net.spectators", Tick: s.Game.Ticks, At: s.Game.Elapsed(), Fields: Fields{"count": len(s.spectators)}}
+	for _, p := range s.players {
+		p.renderer.Render(rec)
+	}
+}
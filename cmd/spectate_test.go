@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func startSpectated(t *testing.T, delay time.Duration) (string, string) {
+	t.Helper()
+	sl, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	_, addr, _ := startServer(t, 3, 10*time.Millisecond, func(s *Server) {
+		s.Delay = delay
+		s.Spectate(sl)
+	})
+	return addr, sl.Addr().String()
+}
+
+func spectate(t *testing.T, addr string) *testClient {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	c := &testClient{t: t, conn: conn, r: bufio.NewReader(conn)}
+	c.waitFor("You are watching")
+	return c
+}
+
+func TestSpectatorDelay(t *testing.T) {
+	addr, watch := startSpectated(t, 300*time.Millisecond)
+	a := dial(t, addr)
+	v := spectate(t, watch)
+	a.waitFor("1 watching.")
+	out := v.waitFor("hole 3: mole 3 exposed")
+	assert.Contains(t, out, "You are watching, 300ms behind the game.  Players: 1")
+
+	a.send("whack 1")
+	a.waitFor("bonked out of existence!")
+	hit := time.Now()
+	v.waitFor("player1 whacked mole 1 in hole 1!")
+	assert.GreaterOrEqual(t, time.Since(hit), 200*time.Millisecond)
+	v.waitFor("hole 1: empty")
+
+	v.send("whack 2")
+	v.waitFor("Spectators can't play, just watch.")
+	v.conn.Close()
+	a.waitFor("0 watching.")
+}
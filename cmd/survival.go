@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Modes are presets over the rest of the config.  In survival mode the
+// moles keep breeding and the game is about lasting as long as possible
+// before they overrun the board, with a "survival.minute" event for every
+// minute survived.
+var Modes = []string{"classic", "survival"}
+
+// ApplyMode sets up c for the named mode on a board of the given size,
+// leaving alone anything already set.  Survival games last until the moles
+// win, so they can't have a time limit.
+func (c *Config) ApplyMode(name string, holes int) error {
+	switch name {
+	case "", "classic":
+		c.Mode = "classic"
+	case "survival":
+		if c.TimeLimit != 0 {
+			return fmt.Errorf("survival mode can't have a time limit")
+		}
+		c.Mode = name
+		if c.Breeding.Rate == 0 {
+			c.Breeding.Rate = 0.05
+		}
+		if c.Breeding.Overrun == 0 {
+			c.Breeding.Overrun = 2 * holes
+		}
+	default:
+		return fmt.Errorf("unknown mode %q, pick one of %s", name, strings.Join(Modes, ", "))
+	}
+	return nil
+}
+
+func (g *Game) survivalCheck() {
+	if g.Config.Mode != "survival" || g.State == End {
+		return
+	}
+	if minutes := int(g.Elapsed().Minutes()); minutes > g.Survived {
+		g.Survived = minutes
+		g.emit("survival.minute", Fields{"minutes": minutes})
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Terrain controls how the board changes during a game.  Each tick every
+// open hole caves in with CollapseChance and stays unusable for
+// CollapseTicks, and a new hole opens with OpenChance until the board has
+// MaxHoles.  Plugs is how many plugs the player starts with.
+type Terrain struct {
+	CollapseChance float64
+	CollapseTicks  int
+	OpenChance     float64
+	MaxHoles       int
+	Plugs          int
+}
+
+// Collapse caves in h until the tick reopen.  A mole inside is pushed out
+// and returned so the caller can find it a new home.
+func (hs *HoleSet) Collapse(h *Hole, reopen int) *Mole {
+	var m *Mole
+	if h.State == Occupied {
+		m = h.OccupyingMole
+		h.Free()
+	}
+	if h.State != Unoccupied {
+		return m
+	}
+	hs.RemoveAvailable(h)
+	_ = hs.addToMap(hs.Collapsed, h)
+	h.State = Collapsed
+	h.ReopenTick = reopen
+	return m
+}
+
+func (hs *HoleSet) Reopen(h *Hole) {
+	if h.State != Collapsed {
+		return
+	}
+	delete(hs.Collapsed, h.ID)
+	_ = hs.AddAvailable(h)
+	h.State = Unoccupied
+	h.ReopenTick = 0
+}
+
+func (hs *HoleSet) Block(h *Hole) error {
+	if h.State != Unoccupied {
+		return fmt.Errorf("hole %d is not empty", h.ID)
+	}
+	hs.RemoveAvailable(h)
+	h.State = Blocked
+	return hs.addToMap(hs.Blocked, h)
+}
+
+func (hs *HoleSet) Unblock(h *Hole) error {
+	if h.State != Blocked {
+		return fmt.Errorf("hole %d is not blocked", h.ID)
+	}
+	delete(hs.Blocked, h.ID)
+	h.State = Unoccupied
+	return hs.AddAvailable(h)
+}
+
+func (g *Game) ProcessTerrain() {
+	t := g.Config.Terrain
+	hs := &g.HoleFactory.HoleSet
+	for _, h := range sortedHoles(hs.Collapsed) {
+		if h.ReopenTick <= g.Ticks {
+			hs.Reopen(h)
+			g.emit("hole.reopened", Fields{"hole": h.ID})
+		}
+	}
+	if t.CollapseChance > 0 {
+		for _, h := range hs.Index.Holes {
+			if h.State != Unoccupied && h.State != Occupied {
+				continue
+			}
+			if g.Rand.Float64() >= t.CollapseChance {
+				continue
+			}
+			g.CollapseHole(h, t.CollapseTicks)
+		}
+	}
+	if t.OpenChance > 0 && (t.MaxHoles == 0 || g.HoleFactory.HoleId < t.MaxHoles) && g.Rand.Float64() < t.OpenChance {
+		if h, err := g.HoleFactory.NewHole(); err == nil {
+			if g.Tunnels != nil {
+				g.Tunnels.AddHole(h.ID, g.Rand)
+			}
+			g.emit("hole.opened", Fields{"hole": h.ID})
+		}
+	}
+}
+
+// CollapseHole caves in h for the given number of ticks and sends any mole
+// inside off to another hole.
+func (g *Game) CollapseHole(h *Hole, ticks int) {
+	hs := &g.HoleFactory.HoleSet
+	m := hs.Collapse(h, g.Ticks+ticks)
+	g.emit("hole.collapsed", Fields{"hole": h.ID, "ticks": ticks})
+	if m == nil {
+		return
+	}
+	if g.Tunnels != nil {
+		m.Node = h.ID
+		g.routeMole(m)
+		return
+	}
+	m.TryOccupy(hs)
+}
+
+// targetHole finds the hole named by args[0].  If there isn't one it says
+// so with the command's own no_hole or unknown_hole message under prefix,
+// filled in with f.
+func (g *Game) targetHole(args []string, prefix string, f Fields) *Hole {
+	if len(args) == 0 {
+		g.respond(prefix+".no_hole", f)
+		return nil
+	}
+	unknown := Fields{"hole": args[0]}
+	for k, v := range f {
+		unknown[k] = v
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		g.respond(prefix+".unknown_hole", unknown)
+		return nil
+	}
+	h := g.HoleFactory.HoleSet.GetHole(id)
+	if h == nil {
+		g.respond(prefix+".unknown_hole", unknown)
+	}
+	return h
+}
+
+func (g *Game) handlePlug(args []string) {
+	if g.Plugs <= 0 {
+		g.respond("plug.none", nil)
+		return
+	}
+	h := g.targetHole(args, "plug", nil)
+	if h == nil {
+		return
+	}
+	if err := g.HoleFactory.HoleSet.Block(h); err != nil {
+		g.respond("plug.bad_hole", Fields{"hole": h.ID})
+		return
+	}
+	g.Plugs--
+	g.respond("plug.placed", Fields{"hole": h.ID, "plugs": g.Plugs})
+}
+
+func (g *Game) handleUnplug(args []string) {
+	h := g.targetHole(args, "plug", nil)
+	if h == nil {
+		return
+	}
+	if err := g.HoleFactory.HoleSet.Unblock(h); err != nil {
+		g.respond("plug.not_plugged", Fields{"hole": h.ID})
+		return
+	}
+	g.Plugs++
+	g.respond("plug.removed", Fields{"hole": h.ID, "plugs": g.Plugs})
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollapseEvictsMole(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.Config.Entropy = 0
+	g.Init(3, 1)
+	hs := &g.HoleFactory.HoleSet
+	m := g.MoleFactory.MoleSet.Housed[1]
+	h := m.HoleOccupied
+	m.ToggleState()
+
+	g.CollapseHole(h, 2)
+	assert.Equal(t, Collapsed, h.State)
+	assert.Nil(t, h.OccupyingMole)
+	assert.Equal(t, h, hs.Collapsed[h.ID])
+	assert.Equal(t, h, hs.GetHole(h.ID))
+	assert.Equal(t, HidingAlive, m.State)
+	assert.NotEqual(t, h, m.HoleOccupied)
+	assert.Len(t, hs.Available, 1)
+
+	g.ProcessPlayerInput("whack 1")
+	assert.Contains(t, buf.String(), "whiff")
+
+	g.ProcessTick()
+	assert.Equal(t, Collapsed, h.State)
+	g.ProcessTick()
+	assert.Equal(t, Unoccupied, h.State)
+	assert.Empty(t, hs.Collapsed)
+	assert.Contains(t, buf.String(), "hole 1 has been dug out again.")
+}
+
+func TestCollapseWithNowhereToGo(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.Config.Entropy = 0
+	g.Config.Terrain = Terrain{CollapseChance: 1, CollapseTicks: 1}
+	g.Init(2, 2)
+	g.ProcessTick()
+	assert.Len(t, g.HoleFactory.HoleSet.Collapsed, 2)
+	assert.Len(t, g.MoleFactory.MoleSet.Unhoused, 2)
+
+	g.Config.Terrain.CollapseChance = 0
+	g.ProcessTick()
+	assert.Len(t, g.MoleFactory.MoleSet.Housed, 2)
+}
+
+func TestOpenHoles(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.Config.Entropy = 0
+	g.Config.Terrain = Terrain{OpenChance: 1, MaxHoles: 3}
+	g.Init(1, 2)
+	assert.Len(t, g.MoleFactory.MoleSet.Unhoused, 1)
+	g.ProcessTick()
+	g.ProcessTick()
+	g.ProcessTick()
+	assert.Equal(t, 3, g.HoleFactory.HoleId)
+	assert.Len(t, g.MoleFactory.MoleSet.Housed, 2)
+	assert.Contains(t, buf.String(), "a new hole 3 has opened up!")
+}
+
+func TestPlugs(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.Config.Terrain.Plugs = 1
+	g.Init(3, 1)
+	hs := &g.HoleFactory.HoleSet
+
+	g.ProcessPlayerInput("plug 1")
+	assert.Contains(t, buf.String(), "Can't plug that")
+	g.ProcessPlayerInput("plug 2")
+	assert.Contains(t, buf.String(), "You plug hole 2. 0 plugs left.")
+	assert.Equal(t, Blocked, hs.GetHole(2).State)
+	g.ProcessPlayerInput("plug 3")
+	assert.Contains(t, buf.String(), "out of plugs")
+
+	g.MoleFactory.MoleSet.Housed[1].Tunnel(hs)
+	g.MoleFactory.MoleSet.Housed[1].Tunnel(hs)
+	assert.NotEqual(t, 2, g.MoleFactory.MoleSet.Housed[1].HoleOccupied.ID)
+
+	buf.Reset()
+	g.ProcessPlayerInput("holes")
+	assert.Contains(t, buf.String(), "hole: 2 (blocked)")
+
+	g.ProcessPlayerInput("unplug 3")
+	assert.Contains(t, buf.String(), "no plug in that hole")
+	g.ProcessPlayerInput("unplug 2")
+	require.Equal(t, 1, g.Plugs)
+	assert.Equal(t, Unoccupied, hs.GetHole(2).State)
+	g.ProcessPlayerInput("plug 9")
+	assert.Contains(t, buf.String(), "There's no hole 9!")
+}
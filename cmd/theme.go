@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Theme gives the ANSI colour of each hole and mole state, as the SGR
+// parameters that go between "\033[" and "m".
+type Theme map[string]string
+
+func DefaultTheme() Theme {
+	return Theme{
+		"exposed":     "1;31",
+		"hiding":      "33",
+		"empty":       "2",
+		"open":        "32",
+		"collapsed":   "35",
+		"blocked":     "36",
+		"whacked":     "2",
+		"underground": "34",
+	}
+}
+
+// themed says which state each message and word is coloured as.  Whole
+// messages are only coloured when they fit on one line.
+var themed = map[string]string{
+	"word.exposed":          "exposed",
+	"word.exposed_in":       "exposed",
+	"word.hiding":           "hiding",
+	"word.hiding_in":        "hiding",
+	"word.empty":            "empty",
+	"word.open":             "open",
+	"word.collapsed":        "collapsed",
+	"word.blocked":          "blocked",
+	"word.whacked":          "whacked",
+	"word.underground":      "underground",
+	"mole.appeared":         "exposed",
+	"mole.appeared_armored": "exposed",
+	"mole.vanished":         "underground",
+	"hole.collapsed":        "collapsed",
+	"hole.reopened":         "open",
+	"hole.opened":           "open",
+}
+
+var colours = map[string]string{
+	"bold":      "1",
+	"dim":       "2",
+	"underline": "4",
+	"reverse":   "7",
+	"black":     "30",
+	"red":       "31",
+	"green":     "32",
+	"yellow":    "33",
+	"blue":      "34",
+	"magenta":   "35",
+	"cyan":      "36",
+	"white":     "37",
+}
+
+// ParseTheme changes the colours of t as given by s, such as
+// "exposed=bold+red,hiding=yellow".  A state set to "none" isn't coloured.
+func ParseTheme(t Theme, s string) (Theme, error) {
+	out := make(Theme, len(t))
+	for state, code := range t {
+		out[state] = code
+	}
+	if s == "" {
+		return out, nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		state, spec, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if _, known := t[state]; !ok || !known {
+			return nil, fmt.Errorf("bad theme entry %q, states are %s", part, strings.Join(themeStates(t), ", "))
+		}
+		if spec == "none" {
+			out[state] = ""
+			continue
+		}
+		var codes []string
+		for _, name := range strings.Split(spec, "+") {
+			code, ok := colours[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown colour %q", name)
+			}
+			codes = append(codes, code)
+		}
+		out[state] = strings.Join(codes, ";")
+	}
+	return out, nil
+}
+
+func themeStates(t Theme) []string {
+	var states []string
+	for state := range t {
+		states = append(states, state)
+	}
+	sort.Strings(states)
+	return states
+}
+
+// paint colours s as the state key is themed as, leaving any trailing
+// newline outside the colour.
+func (t Theme) paint(key string, s string) string {
+	code := t[themed[key]]
+	if code == "" {
+		return s
+	}
+	body := strings.TrimRight(s, "\n")
+	if strings.Contains(body, "\n") {
+		return s
+	}
+	return "\033[" + code + "m" + body + "\033[0m" + s[len(body):]
+}
+
+// useColour decides whether output to out is coloured.  Mode is always,
+// never or auto, which colours terminals unless NO_COLOR is set.
+func useColour(mode string, out io.Writer) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		if os.Getenv("NO_COLOR") != "" {
+			return false, nil
+		}
+		f, ok := out.(*os.File)
+		if !ok {
+			return false, nil
+		}
+		fi, err := f.Stat()
+		return err == nil && fi.Mode()&os.ModeCharDevice != 0, nil
+	}
+	return false, fmt.Errorf("unknown colour mode %q, use auto, always or never", mode)
+}
+
+// alerts are the messages an accessible renderer rings the bell for: a
+// mole has come up and can be whacked.
+var alerts = map[string]bool{
+	"mole.appeared":         true,
+	"mole.appeared_armored": true,
+	"board.frenzy":          true,
+}
+
+// AccessibleTick is the tick accessibility mode slows the game to, unless
+// the player picks one.
+const AccessibleTick = 2 * time.Second
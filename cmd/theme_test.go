@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTheme(t *testing.T) {
+	th, err := ParseTheme(DefaultTheme(), "exposed=bold+green, hiding=none")
+	require.NoError(t, err)
+	assert.Equal(t, "1;32", th["exposed"])
+	assert.Equal(t, "", th["hiding"])
+	assert.Equal(t, DefaultTheme()["open"], th["open"])
+	assert.Equal(t, "1;31", DefaultTheme()["exposed"])
+
+	_, err = ParseTheme(DefaultTheme(), "volcano=red")
+	assert.Error(t, err)
+	_, err = ParseTheme(DefaultTheme(), "exposed=plaid")
+	assert.Error(t, err)
+	_, err = ParseTheme(DefaultTheme(), "exposed")
+	assert.Error(t, err)
+}
+
+func TestThemePaint(t *testing.T) {
+	th := DefaultTheme()
+	assert.Equal(t, "\033[1;31mexposed\033[0m", th.paint("word.exposed", "exposed"))
+	assert.Equal(t, "\033[1;31mmole 1 appeared in hole 2!\033[0m\n", th.paint("mole.appeared", "mole 1 appeared in hole 2!\n"))
+	assert.Equal(t, "SHLONK!\nbonked\n", th.paint("mole.appeared", "SHLONK!\nbonked\n"))
+	assert.Equal(t, "ready", th.paint("word.ready", "ready"))
+	assert.Equal(t, "exposed", Theme(nil).paint("word.exposed", "exposed"))
+}
+
+func TestUseColour(t *testing.T) {
+	var buf bytes.Buffer
+	on, err := useColour("always", &buf)
+	require.NoError(t, err)
+	assert.True(t, on)
+	on, _ = useColour("never", &buf)
+	assert.False(t, on)
+	on, _ = useColour("auto", &buf)
+	assert.False(t, on, "a buffer isn't a terminal")
+	_, err = useColour("sometimes", &buf)
+	assert.Error(t, err)
+
+	t.Setenv("NO_COLOR", "1")
+	on, _ = useColour("auto", os.Stdout)
+	assert.False(t, on)
+	on, _ = useColour("always", os.Stdout)
+	assert.True(t, on, "asking for colour outright beats NO_COLOR")
+}
+
+func TestTextRendererTheme(t *testing.T) {
+	var buf bytes.Buffer
+	r := &TextRenderer{Out: &buf, Theme: DefaultTheme()}
+	r.Render(Record{Type: ResponseRecord, Key: "peek.free", Items: []Fields{
+		{"hole": 1, "status": word("exposed")},
+		{"hole": 2, "status": Text{Key: "word.mole_status", Fields: Fields{"mole": 3, "status": word("hiding")}}},
+	}})
+	assert.Equal(t, "Radar sweep:\n  hole 1: \033[1;31mexposed\033[0m\n  hole 2: mole 3 \033[33mhiding\033[0m\n", buf.String())
+}
+
+func TestTextRendererAccessible(t *testing.T) {
+	var buf bytes.Buffer
+	r := &TextRenderer{Out: &buf, Accessible: true}
+	r.Prompt()
+	r.Render(Record{Type: EventRecord, Key: "mole.appeared", Fields: Fields{"mole": 2, "hole": 5}})
+	r.Render(Record{Type: EventRecord, Key: "mole.vanished", Fields: Fields{"mole": 2}})
+	r.Prompt()
+	assert.Equal(t, "Command: \n\aMole up! mole 2 is out of hole 5, whack 5 now.\nmole 2 vanished!\nCommand: ", buf.String())
+}
+
+func TestEventsDontRunIntoInput(t *testing.T) {
+	var buf bytes.Buffer
+	r := &TextRenderer{Out: &buf}
+	r.Prompt()
+	r.Prompt()
+	assert.Equal(t, "> ", buf.String(), "the prompt is only written once")
+
+	// The player is typing after the prompt when a mole comes up.
+	r.Render(Record{Type: EventRecord, Key: "mole.appeared", Fields: Fields{"mole": 1, "hole": 2}})
+	r.Prompt()
+	assert.Equal(t, "> \nmole 1 appeared in hole 2!\n> ", buf.String())
+
+	// Responses follow the player's Enter, so they start on a new line already.
+	buf.Reset()
+	r.Render(Record{Type: ResponseRecord, Key: "pause.resumed"})
+	assert.Equal(t, "Back to whacking!\n", buf.String())
+}
+
+func TestRunWithColourAndA11y(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "moves.txt")
+	require.NoError(t, os.WriteFile(script, []byte("@1s holes\n@1s quit\n"), 0o644))
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-script", script, "-color", "always", "-theme", "open=blue"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, ExitQuit, code)
+	assert.Contains(t, stdout.String(), "hole: 1 (\033[34mopen\033[0m)")
+
+	stdout.Reset()
+	code = run([]string{"-script", script, "-color", "always", "-a11y"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, ExitQuit, code)
+	assert.NotContains(t, stdout.String(), "\033[")
+	assert.Contains(t, stdout.String(), "Command: ")
+
+	code = run([]string{"-theme", "open=plaid"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr.String(), `unknown colour "plaid"`)
+}
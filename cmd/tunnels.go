@@ -0,0 +1,313 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Tunnels configures the network joining the holes.  Without a topology a
+// mole can pop out of any free hole; with one it has to walk the tunnels,
+// spending EdgeTicks underground for every tunnel it follows.  The "file"
+// topology uses Links, usually read with LoadTunnels.
+type Tunnels struct {
+	Topology  string
+	Links     map[int][]int
+	EdgeTicks int
+}
+
+var Topologies = []string{"grid", "ring", "random", "file"}
+
+type TunnelGraph struct {
+	Topology string
+	Edges    map[int][]int
+}
+
+func NewTunnelGraph(topology string) *TunnelGraph {
+	return &TunnelGraph{Topology: topology, Edges: make(map[int][]int)}
+}
+
+func (tg *TunnelGraph) Connect(a int, b int) {
+	if a == b {
+		return
+	}
+	for _, n := range tg.Edges[a] {
+		if n == b {
+			return
+		}
+	}
+	tg.Edges[a] = append(tg.Edges[a], b)
+	tg.Edges[b] = append(tg.Edges[b], a)
+	sort.Ints(tg.Edges[a])
+	sort.Ints(tg.Edges[b])
+}
+
+func (tg *TunnelGraph) Neighbours(id int) []int {
+	return tg.Edges[id]
+}
+
+func linked(tg *TunnelGraph, a int, b int) bool {
+	for _, id := range tg.Neighbours(a) {
+		if id == b {
+			return true
+		}
+	}
+	return false
+}
+
+func gridWidth(n int) int {
+	return int(math.Ceil(math.Sqrt(float64(n))))
+}
+
+// BuildTunnelGraph joins holes 1..holes with the given topology.  Random
+// graphs are a random spanning tree with one extra tunnel per hole so that
+// every hole can be reached.
+func BuildTunnelGraph(t Tunnels, holes int, rng *rand.Rand) (*TunnelGraph, error) {
+	tg := NewTunnelGraph(t.Topology)
+	switch t.Topology {
+	case "grid":
+		w := gridWidth(holes)
+		for id := 1; id <= holes; id++ {
+			if (id-1)%w < w-1 && id+1 <= holes {
+				tg.Connect(id, id+1)
+			}
+			if id+w <= holes {
+				tg.Connect(id, id+w)
+			}
+		}
+	case "ring":
+		for id := 1; id < holes; id++ {
+			tg.Connect(id, id+1)
+		}
+		if holes > 2 {
+			tg.Connect(holes, 1)
+		}
+	case "random":
+		for id := 2; id <= holes; id++ {
+			tg.Connect(id, 1+rng.Intn(id-1))
+		}
+		for id := 1; id <= holes && holes > 1; id++ {
+			tg.Connect(id, 1+rng.Intn(holes))
+		}
+	case "file":
+		for _, id := range sortedKeys(t.Links) {
+			for _, n := range t.Links[id] {
+				tg.Connect(id, n)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown tunnel topology %q", t.Topology)
+	}
+	return tg, nil
+}
+
+// AddHole links a newly opened hole into the network.  Grids and rings are
+// rebuilt around it, other networks get a tunnel to a random hole.
+func (tg *TunnelGraph) AddHole(id int, rng *rand.Rand) {
+	switch tg.Topology {
+	case "grid", "ring":
+		rebuilt, _ := BuildTunnelGraph(Tunnels{Topology: tg.Topology}, id, rng)
+		tg.Edges = rebuilt.Edges
+	default:
+		if id > 1 {
+			tg.Connect(id, 1+rng.Intn(id-1))
+		}
+	}
+}
+
+func sortedKeys(m map[int][]int) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+// LoadTunnels reads a tunnel file for a board of the given number of holes.
+// Each line names a hole followed by the holes it has tunnels to, as in
+// "1 2 4"; blank lines and lines starting with # are skipped.
+func LoadTunnels(r io.Reader, holes int) (map[int][]int, error) {
+	links := make(map[int][]int)
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var ids []int
+		for _, field := range strings.Fields(strings.ReplaceAll(line, ":", " ")) {
+			id, err := strconv.Atoi(field)
+			if err != nil || id < 1 {
+				return nil, fmt.Errorf("line %d: bad hole %q", lineNo, field)
+			}
+			if id > holes {
+				return nil, fmt.Errorf("line %d: no hole %d", lineNo, id)
+			}
+			ids = append(ids, id)
+		}
+		links[ids[0]] = append(links[ids[0]], ids[1:]...)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// routeMole sends a mole that is underground at node off down a tunnel,
+// preferring tunnels that lead to a free hole.
+func (g *Game) routeMole(m *Mole) {
+	links := g.Tunnels.Neighbours(m.Node)
+	if len(links) == 0 {
+		m.Dest = m.Node
+		m.TravelTicks = 0
+		return
+	}
+	var free []int
+	for _, id := range links {
+		if _, ok := g.HoleFactory.HoleSet.Available[id]; ok {
+			free = append(free, id)
+		}
+	}
+	if len(free) > 0 {
+		links = free
+	}
+	m.Dest = links[g.Rand.Intn(len(links))]
+	g.lure(m, links)
+	m.TravelTicks = g.Config.Tunnels.EdgeTicks
+}
+
+// burrow takes a mole out of its hole and sends it down a tunnel.
+func (g *Game) burrow(m *Mole) {
+	if g.Tunnels == nil {
+		m.Tunnel(&g.HoleFactory.HoleSet)
+		return
+	}
+	if h := m.HoleOccupied; h != nil {
+		m.Node = h.ID
+		h.Free()
+	}
+	m.State = TunnelingAlive
+	g.routeMole(m)
+	if m.TravelTicks == 0 {
+		g.surface(m)
+	}
+}
+
+// dig moves a mole that is already underground one tick along its tunnel
+// and lets it surface when it reaches a free hole.
+func (g *Game) dig(m *Mole) {
+	if g.Tunnels == nil {
+		m.Tunnel(&g.HoleFactory.HoleSet)
+		return
+	}
+	if m.Node == 0 {
+		m.TryOccupy(&g.HoleFactory.HoleSet)
+		return
+	}
+	if m.TravelTicks > 0 {
+		m.TravelTicks--
+	}
+	if m.TravelTicks == 0 {
+		g.surface(m)
+	}
+}
+
+// travel carries a controlled mole on along the tunnel the mole player sent
+// it down.  It is never routed anywhere else: if it has no hole to go to,
+// or its hole has been taken by the time it gets there, it comes up in the
+// next free hole instead of wandering the tunnels.
+func (g *Game) travel(m *Mole) {
+	if m.TravelTicks > 0 {
+		m.TravelTicks--
+	}
+	if m.TravelTicks > 0 {
+		return
+	}
+	hs := &g.HoleFactory.HoleSet
+	if h := hs.GetHole(m.Dest); h != nil && h.TryOccupy(m) {
+		m.Node = h.ID
+		return
+	}
+	if m.TryOccupy(hs) {
+		m.Node = m.HoleOccupied.ID
+	}
+}
+
+func (g *Game) surface(m *Mole) {
+	m.Node = m.Dest
+	if h := g.HoleFactory.HoleSet.GetHole(m.Dest); h != nil && h.TryOccupy(m) {
+		return
+	}
+	g.routeMole(m)
+}
+
+func (g *Game) handleMap() {
+	if g.Tunnels == nil {
+		g.respond("map.open", nil)
+		return
+	}
+	var items []Fields
+	for id := 1; id <= g.HoleFactory.HoleId; id++ {
+		state := "open"
+		if h := g.HoleFactory.HoleSet.GetHole(id); h != nil && (h.State == Collapsed || h.State == Blocked) {
+			state = holeStatus(h)
+		}
+		items = append(items, Fields{"hole": id, "state": word(state), "links": g.Tunnels.Neighbours(id)})
+	}
+	f := Fields{"topology": g.Tunnels.Topology}
+	if g.Tunnels.Topology == "grid" {
+		f["drawing"] = g.drawGrid()
+		g.respondList("map.grid", f, items)
+		return
+	}
+	g.respondList("map", f, items)
+}
+
+// drawGrid draws a grid network as boxes joined by tunnels, marking caved
+// in holes with ## and plugged ones with XX.
+func (g *Game) drawGrid() string {
+	n := g.HoleFactory.HoleId
+	w := gridWidth(n)
+	var b strings.Builder
+	for row := 0; row*w < n; row++ {
+		var down strings.Builder
+		for col := 0; col < w && row*w+col < n; col++ {
+			id := row*w + col + 1
+			label := fmt.Sprintf("%2d", id)
+			if h := g.HoleFactory.HoleSet.GetHole(id); h != nil {
+				switch h.State {
+				case Collapsed:
+					label = "##"
+				case Blocked:
+					label = "XX"
+				}
+			}
+			fmt.Fprintf(&b, "[%s]", label)
+			if col < w-1 && id < n {
+				if linked(g.Tunnels, id, id+1) {
+					b.WriteString("--")
+				} else {
+					b.WriteString("  ")
+				}
+			}
+			if linked(g.Tunnels, id, id+w) {
+				down.WriteString("  |   ")
+			} else {
+				down.WriteString("      ")
+			}
+		}
+		b.WriteString("\n")
+		if line := strings.TrimRight(down.String(), " "); line != "" {
+			b.WriteString(line + "\n")
+		}
+	}
+	return b.String()
+}
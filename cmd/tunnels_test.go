@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildTunnelGraph(t *testing.T) {
+	tg, err := BuildTunnelGraph(Tunnels{Topology: "grid"}, 5, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 4}, tg.Neighbours(1))
+	assert.Equal(t, []int{1, 3, 5}, tg.Neighbours(2))
+	assert.Equal(t, []int{2}, tg.Neighbours(3))
+
+	tg, err = BuildTunnelGraph(Tunnels{Topology: "ring"}, 4, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 4}, tg.Neighbours(1))
+	assert.Equal(t, []int{1, 3}, tg.Neighbours(4))
+
+	tg, err = BuildTunnelGraph(Tunnels{Topology: "random"}, 8, rand.New(rand.NewSource(1)))
+	require.NoError(t, err)
+	for id := 1; id <= 8; id++ {
+		assert.NotEmpty(t, tg.Neighbours(id), "hole %d", id)
+	}
+
+	_, err = BuildTunnelGraph(Tunnels{Topology: "maze"}, 4, nil)
+	assert.Error(t, err)
+}
+
+func TestLoadTunnels(t *testing.T) {
+	links, err := LoadTunnels(strings.NewReader("# a line\n1: 2 3\n\n3 4\n"), 4)
+	require.NoError(t, err)
+	tg, err := BuildTunnelGraph(Tunnels{Topology: "file", Links: links}, 4, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2, 3}, tg.Neighbours(1))
+	assert.Equal(t, []int{1, 4}, tg.Neighbours(3))
+	assert.Equal(t, []int{3}, tg.Neighbours(4))
+
+	_, err = LoadTunnels(strings.NewReader("1 two\n"), 4)
+	assert.EqualError(t, err, `line 1: bad hole "two"`)
+	_, err = LoadTunnels(strings.NewReader("1 2\n2 5\n"), 4)
+	assert.EqualError(t, err, "line 2: no hole 5")
+}
+
+func TestMolesFollowTunnels(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.Config.Entropy = 0
+	g.Config.Tunnels = Tunnels{Topology: "ring", EdgeTicks: 2}
+	g.Init(6, 1)
+	m := g.MoleFactory.MoleSet.Housed[1]
+	require.Equal(t, 1, m.HoleOccupied.ID)
+
+	g.burrow(m)
+	assert.Nil(t, m.HoleOccupied)
+	assert.Equal(t, TunnelingAlive, m.State)
+	assert.Contains(t, []int{2, 6}, m.Dest)
+	dest := m.Dest
+
+	g.ProcessTick()
+	assert.Nil(t, m.HoleOccupied)
+	g.ProcessTick()
+	require.NotNil(t, m.HoleOccupied)
+	assert.Equal(t, dest, m.HoleOccupied.ID)
+	assert.Equal(t, HidingAlive, m.State)
+}
+
+func TestMolesRerouteAroundTakenHoles(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.Config.Entropy = 0
+	g.Config.Tunnels = Tunnels{Topology: "ring"}
+	g.Init(3, 3)
+	m := g.MoleFactory.MoleSet.Housed[1]
+
+	g.burrow(m)
+	assert.Nil(t, m.HoleOccupied)
+	assert.Equal(t, 1, m.Dest)
+	assert.Len(t, g.HoleFactory.HoleSet.Available, 1)
+}
+
+func TestMapCommand(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.Init(3, 1)
+	g.ProcessPlayerInput("map")
+	assert.Contains(t, buf.String(), "There are no tunnels")
+
+	buf.Reset()
+	g.Config.Tunnels = Tunnels{Topology: "grid", EdgeTicks: 1}
+	g.Init(4, 1)
+	g.CollapseHole(g.HoleFactory.HoleSet.GetHole(4), 3)
+	g.ProcessPlayerInput("map")
+	assert.Contains(t, buf.String(), "Tunnels (grid):\n[ 1]--[ 2]\n  |     |\n[ 3]--[##]\n")
+
+	buf.Reset()
+	g.Config.Tunnels = Tunnels{Topology: "ring", EdgeTicks: 1}
+	g.Init(3, 1)
+	g.ProcessPlayerInput("map")
+	assert.Contains(t, buf.String(), "Tunnels (ring):\n  hole 1 (open) -> [2 3]\n")
+}
+
+func TestTunnelFlags(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-tunnels", "maze"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr.String(), `unknown tunnel topology "maze"`)
+
+	stderr.Reset()
+	code = run([]string{"-tunnel-file", "does-not-exist"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, ExitError, code)
+
+	stderr.Reset()
+	file := filepath.Join(t.TempDir(), "tunnels.txt")
+	require.NoError(t, os.WriteFile(file, []byte("1 2\n2 12\n"), 0o644))
+	code = run([]string{"-holes", "9", "-tunnel-file", file}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, ExitError, code)
+	assert.Equal(t, file+": line 2: no hole 12\n", stderr.String())
+
+	stderr.Reset()
+	code = run([]string{"-tunnels", "ring", "-edge-ticks", "-1"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, ExitError, code)
+	assert.Contains(t, stderr.String(), "edge ticks can't be negative")
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// In versus mode a second player controls some of the moles.  Sharing a
+// terminal, their commands start with "m ", as in "m tunnel 3".  Controlled
+// moles are left alone by ProcessMoleMoves and only move when told to, going
+// through the same ToggleState and TryOccupy calls as the moles the game
+// moves itself.
+
+// ControlMoles hands the lowest numbered n moles to the mole player.
+func (g *Game) ControlMoles(n int) {
+	for _, m := range g.MoleFactory.MoleSet.Index.Moles {
+		if n <= 0 {
+			return
+		}
+		m.Controlled = true
+		n--
+	}
+}
+
+func (g *Game) controlledMoles() []*Mole {
+	var moles []*Mole
+	for _, m := range g.MoleFactory.MoleSet.Index.Moles {
+		if m.Controlled {
+			moles = append(moles, m)
+		}
+	}
+	return moles
+}
+
+// controlledMole picks the mole a command is for: the one named by arg, or
+// the lowest numbered controlled mole still alive.
+func (g *Game) controlledMole(arg string) *Mole {
+	if arg == "" {
+		for _, m := range g.controlledMoles() {
+			if m.State != Dead {
+				return m
+			}
+		}
+		g.respond("versus.none_left", nil)
+		return nil
+	}
+	id, err := strconv.Atoi(arg)
+	if err == nil {
+		for _, m := range g.controlledMoles() {
+			if m.ID == id && m.State != Dead {
+				return m
+			}
+		}
+	}
+	g.respond("versus.no_mole", Fields{"mole": arg})
+	return nil
+}
+
+func argAt(args []string, i int) string {
+	if i < len(args) {
+		return args[i]
+	}
+	return ""
+}
+
+func (g *Game) ProcessMoleInput(line string) {
+	parts := strings.Fields(line)
+	if len(parts) == 0 {
+		g.handleMoleList()
+		return
+	}
+	switch parts[0] {
+	case "hide":
+		g.handleMoleHide(argAt(parts, 1))
+	case "expose":
+		g.handleMoleExpose(argAt(parts, 1))
+	case "tunnel":
+		g.handleMoleTunnel(argAt(parts, 1), argAt(parts, 2))
+	case "peek-hammer":
+		g.handlePeekHammer()
+	case "moles":
+		g.handleMoleList()
+	case "quit":
+		g.handleQuit()
+	default:
+		g.respond("versus.unknown", Fields{"command": parts[0]})
+	}
+}
+
+func moleStatus(m *Mole) Text {
+	switch {
+	case m.State == Dead:
+		return word("whacked")
+	case m.HoleOccupied == nil:
+		return word("underground")
+	case m.State == ExposedAlive:
+		return Text{Key: "word.exposed_in", Fields: Fields{"hole": m.HoleOccupied.ID}}
+	default:
+		return Text{Key: "word.hiding_in", Fields: Fields{"hole": m.HoleOccupied.ID}}
+	}
+}
+
+func (g *Game) handleMoleList() {
+	var items []Fields
+	for _, m := range g.controlledMoles() {
+		items = append(items, Fields{"mole": m.ID, "status": moleStatus(m)})
+	}
+	g.respondList("versus.moles", nil, items)
+}
+
+func (g *Game) handleMoleHide(arg string) {
+	m := g.controlledMole(arg)
+	if m == nil {
+		return
+	}
+	if m.HoleOccupied == nil {
+		g.respond("versus.not_housed", Fields{"mole": m.ID})
+		return
+	}
+	if m.State != ExposedAlive {
+		g.respond("versus.already", Fields{"mole": m.ID, "state": word("hiding")})
+		return
+	}
+	m.ToggleState()
+	g.emit("mole.vanished", Fields{"mole": m.ID})
+	g.respond("versus.hidden", Fields{"mole": m.ID, "hole": m.HoleOccupied.ID})
+}
+
+func (g *Game) handleMoleExpose(arg string) {
+	m := g.controlledMole(arg)
+	if m == nil {
+		return
+	}
+	if m.HoleOccupied == nil {
+		g.respond("versus.not_housed", Fields{"mole": m.ID})
+		return
+	}
+	if m.State == ExposedAlive {
+		g.respond("versus.already", Fields{"mole": m.ID, "state": word("exposed")})
+		return
+	}
+	m.ToggleState()
+	key := "mole.appeared"
+	if m.Armored {
+		key = "mole.appeared_armored"
+	}
+	if g.visible(m.HoleOccupied.ID) {
+		g.emit(key, Fields{"mole": m.ID, "hole": m.HoleOccupied.ID})
+	}
+	g.respond("versus.exposed", Fields{"mole": m.ID, "hole": m.HoleOccupied.ID})
+}
+
+// handleMoleTunnel moves a mole to another hole.  On a tunnel network the
+// hole has to be linked to the mole's hole and the trip takes EdgeTicks,
+// otherwise the mole comes straight up in the new hole.  A mole already on
+// its way somewhere has to get there first.
+func (g *Game) handleMoleTunnel(hole string, arg string) {
+	if hole == "" {
+		g.respond("versus.no_hole", nil)
+		return
+	}
+	m := g.controlledMole(arg)
+	if m == nil {
+		return
+	}
+	id, err := strconv.Atoi(hole)
+	target := g.HoleFactory.HoleSet.GetHole(id)
+	if err != nil || target == nil {
+		g.respond("versus.bad_hole", Fields{"hole": hole})
+		return
+	}
+	from := m.HoleOccupied
+	if from == nil || m.TravelTicks > 0 {
+		g.respond("versus.travelling", Fields{"mole": m.ID})
+		return
+	}
+	if target.State != Unoccupied {
+		g.respond("versus.blocked", Fields{"mole": m.ID, "hole": id})
+		return
+	}
+	if g.Tunnels != nil && !linked(g.Tunnels, from.ID, id) {
+		g.respond("versus.no_tunnel", Fields{"from": from.ID, "hole": id})
+		return
+	}
+	g.emit("mole.vanished", Fields{"mole": m.ID})
+	from.Free()
+	if g.Tunnels != nil && g.Config.Tunnels.EdgeTicks > 0 {
+		m.Node, m.Dest, m.TravelTicks = from.ID, id, g.Config.Tunnels.EdgeTicks
+		g.respond("versus.tunneling", Fields{"mole": m.ID, "hole": id, "ticks": m.TravelTicks})
+		return
+	}
+	if !target.TryOccupy(m) {
+		g.winCheck()
+		return
+	}
+	m.Node = id
+	g.respond("versus.tunneled", Fields{"mole": m.ID, "hole": id})
+}
+
+// handlePeekHammer tells the mole player what the whacker is holding and
+// where they swung last.
+func (g *Game) handlePeekHammer() {
+	hm := g.Armory.Hammer()
+	status := word("ready")
+	if wait := g.Armory.ReadyAt[hm.Name] - g.Elapsed(); wait > 0 {
+		status = Text{Key: "word.cooling_for", Fields: Fields{"wait": wait.Round(100 * time.Millisecond)}}
+	}
+	last := word("nowhere_yet")
+	if g.Armory.LastTarget > 0 {
+		last = Text{Key: "word.hole", Fields: Fields{"hole": g.Armory.LastTarget}}
+	}
+	g.respond("versus.hammer", Fields{"hammer": hm.Name, "status": status, "last": last})
+}
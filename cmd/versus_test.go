@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestControlledMolesStayPut(t *testing.T) {
+	g, _, _ := newTestGame(4, 2, versusRules)
+	m := g.MoleFactory.MoleSet.Housed[1]
+	require.True(t, m.Controlled)
+	assert.False(t, g.MoleFactory.MoleSet.Housed[2].Controlled)
+	for range 5 {
+		g.ProcessTick()
+	}
+	assert.Equal(t, HidingAlive, m.State)
+	assert.Equal(t, 1, m.HoleOccupied.ID)
+}
+
+func TestMolePlayerCommands(t *testing.T) {
+	g, _, buf := newTestGame(4, 2, versusRules)
+	m := g.MoleFactory.MoleSet.Housed[1]
+
+	g.ProcessPlayerInput("m expose")
+	assert.Equal(t, ExposedAlive, m.State)
+	assert.Contains(t, buf.String(), "mole 1 appeared in hole 1!\n[moles] mole 1 pops up in hole 1!\n")
+	g.ProcessPlayerInput("m expose 1")
+	assert.Contains(t, buf.String(), "[moles] mole 1 is already exposed.")
+
+	g.ProcessPlayerInput("m hide")
+	assert.Equal(t, HidingAlive, m.State)
+	assert.Contains(t, buf.String(), "[moles] mole 1 ducks down in hole 1.")
+
+	g.ProcessPlayerInput("m tunnel 2")
+	assert.Contains(t, buf.String(), "[moles] hole 2 isn't free, mole 1 stays put.")
+	g.ProcessPlayerInput("m tunnel 4")
+	assert.Equal(t, 4, m.HoleOccupied.ID)
+	assert.Equal(t, m, g.HoleFactory.HoleSet.Unavailable[4].OccupyingMole)
+	assert.Contains(t, g.HoleFactory.HoleSet.Available, 1)
+	g.ProcessPlayerInput("m tunnel 9")
+	assert.Contains(t, buf.String(), "[moles] There's no hole 9!")
+	g.ProcessPlayerInput("m hide 2")
+	assert.Contains(t, buf.String(), "[moles] You have no mole 2 to move.")
+
+	buf.Reset()
+	g.ProcessPlayerInput("m moles")
+	assert.Equal(t, "[moles] Your moles:\n  mole 1: hiding in hole 4\n> ", buf.String())
+	g.ProcessPlayerInput("m dance")
+	assert.Contains(t, buf.String(), "Unknown command dance")
+}
+
+func TestMolePlayerCanBeWhacked(t *testing.T) {
+	g, _, buf := newTestGame(2, 1, versusRules)
+	g.ProcessPlayerInput("m expose")
+	g.ProcessPlayerInput("whack 1")
+	assert.Equal(t, Won, g.Outcome)
+
+	buf.Reset()
+	g.Init(2, 1)
+	g.MoleFactory.MoleSet.Housed[1].ToggleState()
+	g.ProcessPlayerInput("m peek-hammer")
+	assert.Contains(t, buf.String(), "The whacker holds the mallet (ready), last swing at nowhere yet.")
+	g.Config.Hammers = []Hammer{{Name: "heavy", Cooldown: 4 * time.Second, Piercing: true}}
+	g.Armory = NewArmory(g.Config.Hammers)
+	g.ProcessPlayerInput("whack 2")
+	g.ProcessPlayerInput("m peek-hammer")
+	assert.Contains(t, buf.String(), "The whacker holds the heavy (cooling for 4s), last swing at hole 2.")
+}
+
+func TestMolePlayerTunnels(t *testing.T) {
+	g, _, buf := newTestGame(5, 1, ringRules)
+	m := g.MoleFactory.MoleSet.Housed[1]
+
+	g.ProcessPlayerInput("m tunnel 3")
+	assert.Contains(t, buf.String(), "There's no tunnel from hole 1 to hole 3.")
+	g.ProcessPlayerInput("m tunnel 5")
+	assert.Nil(t, m.HoleOccupied)
+	g.ProcessPlayerInput("m tunnel 4")
+	assert.Contains(t, buf.String(), "[moles] mole 1 is still travelling, wait for it to come up.\n")
+	g.ProcessTick()
+	assert.Nil(t, m.HoleOccupied)
+	g.ProcessTick()
+	require.NotNil(t, m.HoleOccupied)
+	assert.Equal(t, 5, m.HoleOccupied.ID)
+}
+
+func TestMolePlayerTunnelTaken(t *testing.T) {
+	g, _, _ := newTestGame(5, 2, ringRules)
+	m := g.MoleFactory.MoleSet.Housed[1]
+	g.ProcessPlayerInput("m tunnel 5")
+	require.NoError(t, g.HoleFactory.HoleSet.Block(g.HoleFactory.HoleSet.GetHole(5)))
+	g.ProcessTick()
+	g.ProcessTick()
+
+	// The mole isn't sent off down another tunnel, it comes up in the next
+	// free hole.
+	require.NotNil(t, m.HoleOccupied)
+	assert.Equal(t, 1, m.HoleOccupied.ID)
+	assert.Equal(t, 0, m.TravelTicks)
+}
+
+func TestMoleCommandsNeedVersus(t *testing.T) {
+	var buf bytes.Buffer
+	g := NewGame(&buf)
+	g.Init(2, 1)
+	g.ProcessPlayerInput("m hide")
+	assert.Contains(t, buf.String(), "unknown commands")
+}
+
+func versusRules(g *Game) {
+	g.Config.Entropy = 100
+	g.Config.Versus = 1
+}
+
+func ringRules(g *Game) {
+	g.Config.Entropy = 0
+	g.Config.Versus = 1
+	g.Config.Tunnels = Tunnels{Topology: "ring", EdgeTicks: 2}
+}